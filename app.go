@@ -1,6 +1,11 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/srrmendez/private-api-offers/server"
 )
 
@@ -8,5 +13,33 @@ import (
 // @contact.email sebastian.rodriguez@etecsa.cu
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := server.Migrate(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "merge-duplicates" {
+		flags := flag.NewFlagSet("merge-duplicates", flag.ExitOnError)
+		dryRun := flags.Bool("dry-run", false, "report the duplicates without merging them")
+
+		flags.Parse(os.Args[2:])
+
+		report, err := server.MergeDuplicates(*dryRun)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		out, _ := json.MarshalIndent(report, "", "  ")
+
+		fmt.Println(string(out))
+
+		return
+	}
+
 	server.Init()
 }
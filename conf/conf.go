@@ -29,6 +29,20 @@ type Category struct {
 	Type     model.OfferType    `yaml:"type"`
 }
 
+// BillingAttribute are the attribute codes carrying the max quantity and the price per extra unit of a resource
+type BillingAttribute struct {
+	MaxQuantity string `yaml:"maxQuantity"`
+	UnitPrice   string `yaml:"unitPrice"`
+}
+
+type TaxRule struct {
+	Name        string             `yaml:"name"`
+	Category    model.CategoryType `yaml:"category"`
+	ClientType  model.ClientType   `yaml:"clientType"`
+	PaymentMode model.PayModeType  `yaml:"paymentMode"`
+	Rate        string             `yaml:"rate"`
+}
+
 type Properties struct {
 	App struct {
 		Path       string `yaml:"appPath"`
@@ -41,9 +55,68 @@ type Properties struct {
 		Database           string `yaml:"database"`
 		Table              string `yaml:"table"`
 		SupplementaryTable string `yaml:"supplementaryTable"`
+		CatalogTable       string `yaml:"catalogTable"`
+		ServiceTypeTable   string `yaml:"serviceTypeTable"`
+		ExchangeRateTable  string `yaml:"exchangeRateTable"`
+		TaxRuleTable       string `yaml:"taxRuleTable"`
+		QuoteTable         string `yaml:"quoteTable"`
+		MigrationTable     string `yaml:"migrationTable"`
+		CacheTable         string `yaml:"cacheTable"`
 	} `yaml:"database"`
 	Categories         map[string]Category `yaml:"categories"`
 	PrivateApiTracking struct {
 		Host string `yaml:"host"`
 	} `yaml:"privateApiTracking"`
+	Mapping struct {
+		Strict            bool                        `yaml:"strict"`
+		UsageAttribute    string                      `yaml:"usageAttribute"`
+		BillingAttributes map[string]BillingAttribute `yaml:"billingAttributes"`
+	} `yaml:"mapping"`
+	Reconcile struct {
+		MaxRemovalRatio float64 `yaml:"maxRemovalRatio"`
+	} `yaml:"reconcile"`
+	References struct {
+		ResolveInterval   int  `yaml:"resolveInterval"`
+		RemoveOrphans     bool `yaml:"removeOrphans"`
+		OrphanGracePeriod int  `yaml:"orphanGracePeriod"`
+	} `yaml:"references"`
+	RelationTypes map[string]model.RelationType `yaml:"relationTypes"`
+	Currency      struct {
+		Default           string            `yaml:"default"`
+		Measures          map[string]string `yaml:"measures"`
+		ExchangeRatesFile string            `yaml:"exchangeRatesFile"`
+	} `yaml:"currency"`
+	Taxes struct {
+		Rules []TaxRule `yaml:"rules"`
+	} `yaml:"taxes"`
+	Quote struct {
+		ValidityMinutes int `yaml:"validityMinutes"`
+	} `yaml:"quote"`
+	Migrations struct {
+		OnStartup bool `yaml:"onStartup"`
+	} `yaml:"migrations"`
+	Storage struct {
+		Backend string `yaml:"backend"`
+		Driver  string `yaml:"driver"`
+		DSN     string `yaml:"dsn"`
+	} `yaml:"storage"`
+	Cache struct {
+		Size   int  `yaml:"size"`
+		TTL    int  `yaml:"ttl"`
+		Shared bool `yaml:"shared"`
+	} `yaml:"cache"`
+}
+
+type ExchangeRates struct {
+	Rates map[string]string `yaml:"rates"`
+}
+
+func LoadExchangeRates(path string) (map[string]string, error) {
+	var rates ExchangeRates
+
+	if err := config.LoadEnvFromYamlFile(path, &rates); err != nil {
+		return nil, err
+	}
+
+	return rates.Rates, nil
 }
@@ -0,0 +1,19 @@
+package model
+
+type BillingType string
+
+const (
+	IncludedBillingType BillingType = "INCLUDED"
+	ExtraBillingType    BillingType = "EXTRA"
+)
+
+// ResourceBilling tells how a resource is charged from the attr_type of its attribute: INCLUDED resources (attr_type
+// 1) come with IncludedQuantity in the offer fare, any other attr_type is an EXTRA resource charged apart from the
+// fare and its offered amount stays on the resource. MaxQuantity and UnitPrice, the price of every extra unit, are
+// read from the attributes configured per resource in mapping.billingAttributes and are left out otherwise
+type ResourceBilling struct {
+	Type             BillingType `json:"type" bson:"type"`
+	IncludedQuantity float64     `json:"included_quantity" bson:"included_quantity"`
+	MaxQuantity      *float64    `json:"max_quantity,omitempty" bson:"max_quantity,omitempty"`
+	UnitPrice        *Money      `json:"unit_price,omitempty" bson:"unit_price,omitempty"`
+}
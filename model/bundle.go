@@ -0,0 +1,22 @@
+package model
+
+type BundleComponent struct {
+	ID         string `json:"id" bson:"id"`
+	ExternalID string `json:"external_id" bson:"external_id"`
+}
+
+// BundlePricing is in the currency of the bundle fare, the component prices are converted to it
+type BundlePricing struct {
+	Fare                     Money `json:"fare"`
+	ActivationFare           Money `json:"activation_fare"`
+	ComponentsFare           Money `json:"components_fare"`
+	ComponentsActivationFare Money `json:"components_activation_fare"`
+	Savings                  Money `json:"savings"`
+	ActivationSavings        Money `json:"activation_savings"`
+}
+
+type Bundle struct {
+	Offer      Offer         `json:"offer"`
+	Components []Offer       `json:"components"`
+	Pricing    BundlePricing `json:"pricing"`
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// CacheEntry is a cached offer read, Value holds its json encoding
+type CacheEntry struct {
+	Key       string    `json:"key" bson:"_id"`
+	Value     []byte    `json:"value" bson:"value"`
+	Tags      []string  `json:"tags" bson:"tags"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+}
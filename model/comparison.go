@@ -0,0 +1,30 @@
+package model
+
+type ComparedOffer struct {
+	ID         string    `json:"id"`
+	ExternalID *string   `json:"external_id,omitempty"`
+	Name       string    `json:"name"`
+	Type       OfferType `json:"type,omitempty"`
+	Fare       Money     `json:"fare"`
+}
+
+// ComparisonCell holds the value of a resource for one offer, quantities are converted to the unit of the row
+type ComparisonCell struct {
+	Value        *float64 `json:"value,omitempty"`
+	Text         string   `json:"text,omitempty"`
+	PricePerUnit *Money   `json:"price_per_unit,omitempty"`
+}
+
+type ComparisonRow struct {
+	Resource  string           `json:"resource"`
+	Unit      string           `json:"unit,omitempty"`
+	Cells     []ComparisonCell `json:"cells"`
+	Different bool             `json:"different"`
+}
+
+// Comparison cells are in the same order as the offers
+type Comparison struct {
+	Currency string          `json:"currency"`
+	Offers   []ComparedOffer `json:"offers"`
+	Rows     []ComparisonRow `json:"rows"`
+}
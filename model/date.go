@@ -0,0 +1,39 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// offerDateLayouts are the formats the effective and expiration dates are accepted in, without zone they are local
+var offerDateLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"20060102150405",
+	"20060102",
+	time.RFC3339,
+}
+
+// ParseOfferDate returns the unix time of an effective or expiration date, unix seconds are accepted too
+func ParseOfferDate(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+
+	for _, layout := range offerDateLayouts {
+		if len(value) != len(layout) && layout != time.RFC3339 {
+			continue
+		}
+
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t.Unix(), true
+		}
+	}
+
+	// compact dates are all digits too, they are matched by length above
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil && seconds > 0 {
+		return seconds, true
+	}
+
+	return 0, false
+}
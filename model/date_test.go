@@ -0,0 +1,36 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOfferDate(t *testing.T) {
+	day := time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local).Unix()
+	noon := time.Date(2024, 3, 15, 12, 30, 0, 0, time.Local).Unix()
+
+	tests := []struct {
+		value  string
+		want   int64
+		wantOk bool
+	}{
+		{value: "2024-03-15", want: day, wantOk: true},
+		{value: "2024-03-15 12:30", want: noon, wantOk: true},
+		{value: "2024-03-15 12:30:00", want: noon, wantOk: true},
+		{value: "20240315", want: day, wantOk: true},
+		{value: "20240315123000", want: noon, wantOk: true},
+		{value: time.Unix(noon, 0).Format(time.RFC3339), want: noon, wantOk: true},
+		{value: "1710505800", want: 1710505800, wantOk: true},
+		{value: " 2024-03-15 ", want: day, wantOk: true},
+		{value: "15/03/2024"},
+		{value: "2024-13-01"},
+		{value: ""},
+	}
+
+	for _, test := range tests {
+		got, ok := ParseOfferDate(test.value)
+		if ok != test.wantOk || got != test.want {
+			t.Errorf("ParseOfferDate(%q) = %d, %t, want %d, %t", test.value, got, ok, test.want, test.wantOk)
+		}
+	}
+}
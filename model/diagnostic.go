@@ -0,0 +1,31 @@
+package model
+
+type DiagnosticType string
+
+const (
+	UnknownAttributeDiagnostic DiagnosticType = "UNKNOWN_ATTRIBUTE"
+	InvalidValueDiagnostic     DiagnosticType = "INVALID_VALUE"
+	ConflictingUnitDiagnostic  DiagnosticType = "CONFLICTING_UNIT"
+	UnknownUnitDiagnostic      DiagnosticType = "UNKNOWN_UNIT"
+
+	UnknownRelationTypeDiagnostic DiagnosticType = "UNKNOWN_RELATION_TYPE"
+	UnknownCurrencyDiagnostic     DiagnosticType = "UNKNOWN_CURRENCY"
+	ConflictingFeeDiagnostic      DiagnosticType = "CONFLICTING_FEE"
+
+	// TypeViolationDiagnostic flags a stored offer whose last synced version broke its type rules and was not stored
+	TypeViolationDiagnostic DiagnosticType = "TYPE_VIOLATION"
+)
+
+type Diagnostic struct {
+	Type    DiagnosticType `json:"type" bson:"type"`
+	Code    string         `json:"attr_code" bson:"attr_code"`
+	Value   string         `json:"attr_value" bson:"attr_value"`
+	Message string         `json:"message" bson:"message"`
+}
+
+type OfferDiagnostics struct {
+	ID          string       `json:"id"`
+	ExternalID  *string      `json:"external_id,omitempty"`
+	Name        string       `json:"name,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
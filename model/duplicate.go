@@ -0,0 +1,13 @@
+package model
+
+type DuplicateReport struct {
+	DryRun bool             `json:"dry_run"`
+	Groups []DuplicateGroup `json:"groups"`
+}
+
+type DuplicateGroup struct {
+	Collection string   `json:"collection"`
+	ExternalID string   `json:"external_id"`
+	KeptID     string   `json:"kept_id"`
+	RemovedIDs []string `json:"removed_ids"`
+}
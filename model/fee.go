@@ -0,0 +1,29 @@
+package model
+
+type FeeType string
+
+const (
+	OneOffFee  FeeType = "ONE_OFF"
+	MonthlyFee FeeType = "MONTHLY"
+	TopupFee   FeeType = "TOPUP"
+	UsageFee   FeeType = "USAGE"
+)
+
+// FeeComponent is one of the charges of an offer, Source is the commercial system field or attribute code it was
+// mapped from.
+//
+// Precedence when an offer is mapped:
+//   - ONE_OFF comes from oneoff_fee.
+//   - MONTHLY comes from monthly_fee, the amount attribute is only used when monthly_fee is zero or missing and a
+//     different amount is reported as a CONFLICTING_FEE diagnostic.
+//   - TOPUP comes from topupFee.
+//   - USAGE comes from the attribute configured as mapping.usageAttribute. The commercial system offer has no usage
+//     fee field and its contract only defines the amount and measureId price attributes, so no usage fee is mapped
+//     while the attribute is not configured.
+//
+// Zero fees are not listed.
+type FeeComponent struct {
+	Type   FeeType `json:"type" bson:"type"`
+	Amount Money   `json:"amount" bson:"amount"`
+	Source string  `json:"source" bson:"source"`
+}
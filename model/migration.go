@@ -0,0 +1,7 @@
+package model
+
+type MigrationRecord struct {
+	Version     int    `json:"version" bson:"_id"`
+	Description string `json:"description" bson:"description"`
+	AppliedAt   string `json:"applied_at" bson:"applied_at"`
+}
@@ -0,0 +1,117 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// Money is an amount stored in cents of its currency so sums and conversions never accumulate float errors
+type Money struct {
+	Cents    int64  `json:"-" bson:"cents"`
+	Currency string `json:"currency" bson:"currency"`
+}
+
+type OfferPrice struct {
+	Fare           Money `json:"fare" bson:"fare"`
+	ActivationFare Money `json:"activation_fare" bson:"activation_fare"`
+}
+
+type ExchangeRate struct {
+	Currency  string `json:"currency" bson:"_id"`
+	Rate      string `json:"rate" bson:"rate"`
+	UpdatedAt string `json:"updated_at" bson:"updated_at"`
+}
+
+var hundred = big.NewRat(100, 1)
+
+// NewMoney rounds the amount half away from zero to cents
+func NewMoney(amount *big.Rat, currency string) Money {
+	cents := new(big.Rat).Mul(amount, hundred)
+
+	num := new(big.Int).Set(cents.Num())
+	den := cents.Denom()
+
+	// round half away from zero: (2*num + sign*den) / (2*den)
+	num.Mul(num, big.NewInt(2))
+
+	if num.Sign() < 0 {
+		num.Sub(num, den)
+	} else {
+		num.Add(num, den)
+	}
+
+	num.Quo(num, new(big.Int).Mul(den, big.NewInt(2)))
+
+	return Money{Cents: num.Int64(), Currency: currency}
+}
+
+// ParseMoney parses a decimal amount such as "12.5" without going through float64
+func ParseMoney(amount string, currency string) (Money, error) {
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount [%s]", amount)
+	}
+
+	return NewMoney(r, currency), nil
+}
+
+func MoneyFromFloat(amount float64, currency string) Money {
+	m, _ := ParseMoney(strconv.FormatFloat(amount, 'f', -1, 64), currency)
+
+	return m
+}
+
+func (m Money) Rat() *big.Rat {
+	return big.NewRat(m.Cents, 100)
+}
+
+func (m Money) Add(o Money) Money {
+	return Money{Cents: m.Cents + o.Cents, Currency: m.Currency}
+}
+
+func (m Money) Sub(o Money) Money {
+	return Money{Cents: m.Cents - o.Cents, Currency: m.Currency}
+}
+
+func (m Money) String() string {
+	return m.Rat().FloatString(2)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   json.Number `json:"amount"`
+		Currency string      `json:"currency"`
+	}{
+		Amount:   json.Number(m.String()),
+		Currency: m.Currency,
+	})
+}
+
+// UnmarshalJSON reads the amount MarshalJSON writes, it is parsed as a decimal so it round-trips to the same cents
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var money struct {
+		Amount   json.Number `json:"amount"`
+		Currency string      `json:"currency"`
+	}
+
+	if err := json.Unmarshal(data, &money); err != nil {
+		return err
+	}
+
+	if money.Amount == "" {
+		*m = Money{Currency: money.Currency}
+
+		return nil
+	}
+
+	parsed, err := ParseMoney(money.Amount.String(), money.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+
+	return nil
+}
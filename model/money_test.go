@@ -0,0 +1,68 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMoneyJSONRoundTrip(t *testing.T) {
+	tests := []Money{
+		{Cents: 1250, Currency: "USD"},
+		{Cents: -99, Currency: "EUR"},
+		{Cents: 0, Currency: "CUP"},
+		{Cents: 1, Currency: "USD"},
+	}
+
+	for _, want := range tests {
+		raw, err := json.Marshal(want)
+		if err != nil {
+			t.Fatalf("marshal %v: %s", want, err)
+		}
+
+		var got Money
+
+		if err = json.Unmarshal(raw, &got); err != nil {
+			t.Fatalf("unmarshal %s: %s", raw, err)
+		}
+
+		if got != want {
+			t.Errorf("round trip of %s = %+v, want %+v", raw, got, want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    Money
+		wantErr bool
+	}{
+		{raw: `{"amount":12.5,"currency":"USD"}`, want: Money{Cents: 1250, Currency: "USD"}},
+		{raw: `{"amount":"0.005","currency":"USD"}`, want: Money{Cents: 1, Currency: "USD"}},
+		{raw: `{"currency":"USD"}`, want: Money{Currency: "USD"}},
+		{raw: `{"amount":"ten","currency":"USD"}`, wantErr: true},
+	}
+
+	for _, test := range tests {
+		var got Money
+
+		err := json.Unmarshal([]byte(test.raw), &got)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("unmarshal %s = %+v, want an error", test.raw, got)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unmarshal %s: %s", test.raw, err)
+
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("unmarshal %s = %+v, want %+v", test.raw, got, test.want)
+		}
+	}
+}
@@ -44,16 +44,44 @@ type Offer struct {
 	Type     OfferType    `json:"type,omitempty" bson:"type,omitempty"`
 
 	DataCenterResourceAttributtes *DataCenterResourceAttributtes `json:"data_center_resource_attributes,omitempty" bson:"data_center_resource_attributes,omitempty"`
+	YellowPagesAttributes         *YellowPagesAttributes         `json:"yellow_pages_attributes,omitempty" bson:"yellow_pages_attributes,omitempty"`
 
 	Temporal bool `json:"temporal"`
 
 	EffectiveDate  string `json:"-" bson:"effective_date,omitempty"`
 	ExpirationDate string `json:"expiration_date,omitempty" bson:"expiration_date,omitempty"`
+	// EffectiveAt and ExpirationAt are the unix times of the dates, the active filter compares them and an offer
+	// without them has no bound
+	EffectiveAt  int64 `json:"-" bson:"effective_at,omitempty"`
+	ExpirationAt int64 `json:"-" bson:"expiration_at,omitempty"`
 
 	Fare            float64  `json:"fare,omitempty" bson:"fare,omitempty"`
 	Currency        *string  `json:"currency,omitempty" bson:"currency,omitempty"`
 	ActivationFare  float64  `json:"activation_fare,omitempty" bson:"activation_fare,omitempty"`
 	Supplementaries []string `json:"supplementaries,omitempty" bson:"supplementaries,omitempty"`
+
+	Price          *OfferPrice `json:"price,omitempty" bson:"price,omitempty"`
+	ConvertedPrice *OfferPrice `json:"converted_price,omitempty" bson:"-"`
+	Taxes          *OfferTaxes `json:"taxes,omitempty" bson:"-"`
+	ConvertedTaxes *OfferTaxes `json:"converted_taxes,omitempty" bson:"-"`
+
+	References []SupplementaryReference `json:"relationships,omitempty" bson:"references,omitempty"`
+	Pending    bool                     `json:"pending,omitempty" bson:"pending"`
+
+	SupplementaryOffers []Offer `json:"supplementary_offers,omitempty" bson:"-"`
+
+	Catalogs []string `json:"catalogs,omitempty" bson:"catalogs,omitempty"`
+
+	Bundle     bool              `json:"bundle,omitempty" bson:"bundle"`
+	Components []BundleComponent `json:"components,omitempty" bson:"components,omitempty"`
+
+	Diagnostics []Diagnostic `json:"-" bson:"diagnostics"`
+
+	// Fees lists every charge of the offer, Price holds the MONTHLY and ONE_OFF ones
+	Fees []FeeComponent `json:"fees,omitempty" bson:"fees,omitempty"`
+
+	// Version is increased on every write, a write made on an older version is rejected
+	Version int64 `json:"version" bson:"version"`
 }
 
 type DataCenterResourceAttributtes struct {
@@ -72,29 +100,54 @@ type DataCenterResourceAttributtes struct {
 	Bandwidth           *BandWith `json:"bandwidth,omitempty" bson:"bandwidth,omitempty"`
 	SaveVM              *bool     `json:"save_vm,omitempty" bson:"save_vm,omitempty"`
 	Port                *Port     `json:"port,omitempty" bson:"port,omitempty"`
-	Included            bool      `json:"included" bson:"included"`
+	// Included is false when any resource is not included in the fare, Billing tells which ones
+	Included bool `json:"included" bson:"included"`
+	// Billing is keyed by resource: cpu, ram, hdd, database_quantity, database_space, ftp, alias,
+	// network_interfaces, public_ips, bandwidth and vpn_speed
+	Billing map[string]ResourceBilling `json:"billing,omitempty" bson:"billing,omitempty"`
+}
+
+// YellowPagesAttributes are mapped from the C_YP_LISTING_TIER, C_YP_AD_SIZE, CN_YP_DURATION, C_YP_DURATION_UNIT,
+// C_YP_REGIONS (comma separated), C_YP_LOGO_FLAG and C_YP_WEBLINK_FLAG attributes
+type YellowPagesAttributes struct {
+	ListingTier  string `json:"listing_tier,omitempty" bson:"listing_tier,omitempty"`
+	AdSize       string `json:"ad_size,omitempty" bson:"ad_size,omitempty"`
+	Duration     *int   `json:"duration,omitempty" bson:"duration,omitempty"`
+	DurationUnit string `json:"duration_unit,omitempty" bson:"duration_unit,omitempty"`
+	// Months is the canonical duration, filters compare it so they work across units
+	Months  int      `json:"months,omitempty" bson:"months,omitempty"`
+	Regions []string `json:"regions,omitempty" bson:"regions,omitempty"`
+	Logo    *bool    `json:"logo,omitempty" bson:"logo,omitempty"`
+	WebLink *bool    `json:"web_link,omitempty" bson:"web_link,omitempty"`
 }
 
 type RAM struct {
 	Amount float64 `json:"amount" bson:"amount"`
 	Unit   string  `json:"unit" bson:"unit"`
+	// Bytes is the canonical amount, filters compare it so they work across units
+	Bytes int64 `json:"bytes,omitempty" bson:"bytes,omitempty"`
 }
 
 type HDD struct {
 	Amount float64 `json:"amount" bson:"amount"`
 	Unit   string  `json:"unit" bson:"unit"`
+	// Bytes is the canonical amount, filters compare it so they work across units
+	Bytes int64 `json:"bytes,omitempty" bson:"bytes,omitempty"`
 }
 
 type Database struct {
 	Quantity int     `json:"quantity" bson:"quantity"`
 	Amount   float64 `json:"amount" bson:"amount"`
 	Unit     string  `json:"unit" bson:"unit"`
+	Bytes    int64   `json:"bytes,omitempty" bson:"bytes,omitempty"`
 }
 
 type BandWith struct {
 	Amount float64    `json:"amount" bson:"amount"`
 	Unit   string     `json:"unit" bson:"unit"`
 	Type   AccessType `json:"type" bson:"type"`
+	// BitsPerSecond is the canonical amount, filters compare it so they work across units
+	BitsPerSecond int64 `json:"bits_per_second,omitempty" bson:"bits_per_second,omitempty"`
 }
 
 type VPN struct {
@@ -102,6 +155,8 @@ type VPN struct {
 	Name      string  `json:"name" bson:"name"`
 	Speed     float64 `json:"speed" bson:"speed"`
 	Unit      string  `json:"unit" bson:"unit"`
+
+	BitsPerSecond int64 `json:"bits_per_second,omitempty" bson:"bits_per_second,omitempty"`
 }
 
 type DNS struct {
@@ -0,0 +1,23 @@
+package model
+
+// OfferEditRequest lists the offer fields an edit can change, fields left out keep their stored value. Prices come
+// from the commercial system, they are accepted so an offer read from the api can be sent back but must be unchanged
+type OfferEditRequest struct {
+	ID      string `json:"-"`
+	Version int64  `json:"-"`
+
+	Name           *string      `json:"name"`
+	ClientType     *ClientType  `json:"client_type"`
+	Paymentmode    *PayModeType `json:"payment_mode"`
+	Temporal       *bool        `json:"temporal"`
+	ExpirationDate *string      `json:"expiration_date"`
+	Catalogs       []string     `json:"catalogs"`
+
+	DataCenterResourceAttributtes *DataCenterResourceAttributtes `json:"data_center_resource_attributes"`
+	YellowPagesAttributes         *YellowPagesAttributes         `json:"yellow_pages_attributes"`
+
+	Fare           *float64       `json:"fare"`
+	ActivationFare *float64       `json:"activation_fare"`
+	Price          *OfferPrice    `json:"price"`
+	Fees           []FeeComponent `json:"fees"`
+}
@@ -0,0 +1,27 @@
+package model
+
+type ReadOptions struct {
+	ExpandSupplementaries bool
+	// ISO currency the prices are converted to, empty keeps the offer currency
+	Currency string
+	// tax rules version the taxes are computed with, 0 uses the version in use
+	TaxVersion int
+}
+
+// SearchFilters ranges are inclusive, storage is in bytes and bandwidth in bits per second
+type SearchFilters struct {
+	RAMMin       *int64
+	RAMMax       *int64
+	HDDMin       *int64
+	HDDMax       *int64
+	DatabaseMin  *int64
+	DatabaseMax  *int64
+	BandwidthMin *int64
+	BandwidthMax *int64
+
+	ListingTier string
+	AdSize      string
+	Region      string
+	// DurationMin is in months
+	DurationMin *int
+}
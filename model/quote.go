@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+type QuoteItemRequest struct {
+	ID       string `json:"id"`
+	Quantity int    `json:"quantity"`
+}
+
+type QuoteRequest struct {
+	OfferID         string             `json:"offer_id"`
+	Quantity        int                `json:"quantity"`
+	Supplementaries []QuoteItemRequest `json:"supplementaries"`
+	// BillingPeriod is the number of months billed, defaults to one
+	BillingPeriod int `json:"billing_period"`
+	// StartDate (YYYY-MM-DD) prorates the first month from that day to the end of the month
+	StartDate string `json:"start_date,omitempty"`
+	// Currency defaults to the currency of the primary offer
+	Currency string `json:"currency,omitempty"`
+}
+
+type QuoteItem struct {
+	OfferID    string       `json:"offer_id" bson:"offer_id"`
+	ExternalID *string      `json:"external_id,omitempty" bson:"external_id,omitempty"`
+	Name       string       `json:"name" bson:"name"`
+	Primary    bool         `json:"primary" bson:"primary"`
+	Relation   RelationType `json:"relation,omitempty" bson:"relation,omitempty"`
+	Quantity   int          `json:"quantity" bson:"quantity"`
+	Temporal   bool         `json:"temporal" bson:"temporal"`
+	// BilledMonths is the decimal number of months the recurring fare is charged, temporal offers are charged once
+	BilledMonths   string      `json:"billed_months" bson:"billed_months"`
+	UnitFare       Money       `json:"unit_fare" bson:"unit_fare"`
+	UnitActivation Money       `json:"unit_activation_fare" bson:"unit_activation_fare"`
+	Activation     TaxedAmount `json:"activation" bson:"activation"`
+	Recurring      TaxedAmount `json:"recurring" bson:"recurring"`
+}
+
+// Quote keeps the tax rules version it was computed with so it can be reproduced after the rules change
+type Quote struct {
+	ID            string      `json:"id" bson:"_id"`
+	OfferID       string      `json:"offer_id" bson:"offer_id"`
+	BillingPeriod int         `json:"billing_period" bson:"billing_period"`
+	StartDate     string      `json:"start_date,omitempty" bson:"start_date,omitempty"`
+	Currency      string      `json:"currency" bson:"currency"`
+	TaxVersion    int         `json:"tax_version,omitempty" bson:"tax_version,omitempty"`
+	Items         []QuoteItem `json:"items" bson:"items"`
+	Activation    TaxedAmount `json:"activation" bson:"activation"`
+	Recurring     TaxedAmount `json:"recurring" bson:"recurring"`
+	Total         TaxedAmount `json:"total" bson:"total"`
+	CreatedAt     string      `json:"created_at" bson:"created_at"`
+	ExpiresAt     time.Time   `json:"expires_at" bson:"expires_at"`
+}
@@ -0,0 +1,39 @@
+package model
+
+// ResourceRequirements quantities accept units, storage is MB and bandwidth Mbps when no unit is given
+type ResourceRequirements struct {
+	CPU        int        `json:"cpu,omitempty"`
+	RAM        string     `json:"ram,omitempty"`
+	HDD        string     `json:"hdd,omitempty"`
+	Databases  int        `json:"database_quantity,omitempty"`
+	Bandwidth  string     `json:"bandwidth,omitempty"`
+	AccessType AccessType `json:"access_type,omitempty"`
+}
+
+type RecommendRequest struct {
+	Requirements ResourceRequirements `json:"requirements"`
+	ClientType   ClientType           `json:"client_type,omitempty"`
+	PaymentMode  PayModeType          `json:"payment_mode,omitempty"`
+	// FillWithSupplementaries completes offers short of some resource with their supplementaries
+	FillWithSupplementaries bool `json:"fill_with_supplementaries"`
+	// Currency the offers are ranked in, defaults to the default currency
+	Currency string `json:"currency,omitempty"`
+	// Limit defaults to 5
+	Limit int `json:"limit,omitempty"`
+}
+
+type RecommendedSupplementary struct {
+	Offer    Offer        `json:"offer"`
+	Relation RelationType `json:"relation,omitempty"`
+	Quantity int          `json:"quantity"`
+}
+
+// Recommendation fares include the supplementaries, OverProvisioning is the mean excess ratio over the requested
+// resources
+type Recommendation struct {
+	Offer            Offer                      `json:"offer"`
+	Supplementaries  []RecommendedSupplementary `json:"supplementaries,omitempty"`
+	Fare             Money                      `json:"fare"`
+	ActivationFare   Money                      `json:"activation_fare"`
+	OverProvisioning float64                    `json:"over_provisioning"`
+}
@@ -0,0 +1,19 @@
+package model
+
+type ReconcileScope struct {
+	Category CategoryType `json:"category"`
+	Type     OfferType    `json:"type"`
+}
+
+type ReconcileReport struct {
+	DryRun   bool              `json:"dry_run"`
+	Scopes   []ReconcileScope  `json:"scopes"`
+	InScope  int               `json:"in_scope"`
+	Removals []OfferSyncResult `json:"removals"`
+	Sync     *SyncReport       `json:"sync,omitempty"`
+
+	// ThresholdExceeded is set when the removals exceed the removal threshold, only a dry run reports them then
+	ThresholdExceeded bool `json:"threshold_exceeded"`
+	// Invalid lists the offers that could not be mapped, they are neither synced nor removed
+	Invalid []OfferSyncResult `json:"invalid"`
+}
@@ -0,0 +1,44 @@
+package model
+
+type ReferenceState string
+type RelationType string
+
+const (
+	PendingReferenceState ReferenceState = "PENDING"
+	MissingReferenceState ReferenceState = "MISSING"
+
+	MandatoryRelationType   RelationType = "MANDATORY"
+	OptionalRelationType    RelationType = "OPTIONAL"
+	ExclusiveRelationType   RelationType = "EXCLUSIVE"
+	BundledRelationType     RelationType = "BUNDLED"
+	UpgradePathRelationType RelationType = "UPGRADE_PATH"
+)
+
+type SupplementaryReference struct {
+	ID         string       `json:"id" bson:"id"`
+	ExternalID string       `json:"external_id" bson:"external_id"`
+	Type       RelationType `json:"type,omitempty" bson:"type,omitempty"`
+}
+
+type DanglingReference struct {
+	PrimaryID               string         `json:"primary_id"`
+	PrimaryExternalID       *string        `json:"primary_external_id,omitempty"`
+	SupplementaryID         string         `json:"supplementary_id,omitempty"`
+	SupplementaryExternalID string         `json:"supplementary_external_id,omitempty"`
+	State                   ReferenceState `json:"state"`
+}
+
+type OrphanSupplementary struct {
+	ID         string  `json:"id"`
+	ExternalID *string `json:"external_id,omitempty"`
+	Pending    bool    `json:"pending"`
+	Removed    bool    `json:"removed"`
+}
+
+type ReferenceReport struct {
+	Dangling []DanglingReference   `json:"dangling"`
+	Orphans  []OrphanSupplementary `json:"orphans"`
+	Relinked int                   `json:"relinked"`
+	// Conflicts lists the primary offers that changed while being relinked, the next run relinks them
+	Conflicts []string `json:"conflicts"`
+}
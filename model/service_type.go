@@ -0,0 +1,9 @@
+package model
+
+type ServiceType struct {
+	Code      string       `json:"code" bson:"_id"`
+	Category  CategoryType `json:"category" bson:"category"`
+	Type      OfferType    `json:"type" bson:"type"`
+	CreatedAt string       `json:"created_at" bson:"created_at"`
+	UpdatedAt string       `json:"updated_at" bson:"updated_at"`
+}
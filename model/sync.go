@@ -0,0 +1,36 @@
+package model
+
+type SyncAction string
+
+const (
+	CreateSyncAction    SyncAction = "CREATE"
+	UpdateSyncAction    SyncAction = "UPDATE"
+	UnchangedSyncAction SyncAction = "UNCHANGED"
+	RemoveSyncAction    SyncAction = "REMOVE"
+	InvalidSyncAction   SyncAction = "INVALID"
+)
+
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Stored interface{} `json:"stored" bson:"stored"`
+	Mapped interface{} `json:"mapped" bson:"mapped"`
+}
+
+type OfferSyncResult struct {
+	ExternalID string        `json:"external_id"`
+	Name       string        `json:"name"`
+	Primary    bool          `json:"primary"`
+	Action     SyncAction    `json:"action"`
+	Changes    []FieldChange `json:"changes,omitempty"`
+	// Violations of the offer type rules, offers with violations are reported as INVALID and not written
+	Violations []AttributeViolation `json:"violations,omitempty"`
+	// Error is the mapping error of an INVALID offer
+	Error string `json:"error,omitempty"`
+	// Written tells the stored offer was written, an UNCHANGED offer with new diagnostics and an INVALID one flagged
+	// with its violations are written too
+	Written bool `json:"-"`
+}
+
+type SyncReport struct {
+	Offers []OfferSyncResult `json:"offers"`
+}
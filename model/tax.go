@@ -0,0 +1,31 @@
+package model
+
+// TaxRule applies its rate to the offers matching every non empty criteria, a rule for the ALL payment mode applies
+// to every offer and an offer sold in ALL payment modes is taxed with its highest taxed payment mode
+type TaxRule struct {
+	Name        string       `json:"name" bson:"name"`
+	Category    CategoryType `json:"category,omitempty" bson:"category,omitempty"`
+	ClientType  ClientType   `json:"client_type,omitempty" bson:"client_type,omitempty"`
+	PaymentMode PayModeType  `json:"payment_mode,omitempty" bson:"payment_mode,omitempty"`
+	Rate        string       `json:"rate" bson:"rate"`
+}
+
+// TaxRuleSet is never modified once stored, changing the rules creates a new version
+type TaxRuleSet struct {
+	Version   int       `json:"version" bson:"_id"`
+	Rules     []TaxRule `json:"rules" bson:"rules"`
+	CreatedAt string    `json:"created_at" bson:"created_at"`
+}
+
+type TaxedAmount struct {
+	Net   Money `json:"net" bson:"net"`
+	Tax   Money `json:"tax" bson:"tax"`
+	Gross Money `json:"gross" bson:"gross"`
+}
+
+type OfferTaxes struct {
+	Version        int         `json:"version" bson:"version"`
+	Rules          []string    `json:"rules" bson:"rules"`
+	Fare           TaxedAmount `json:"fare" bson:"fare"`
+	ActivationFare TaxedAmount `json:"activation_fare" bson:"activation_fare"`
+}
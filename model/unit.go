@@ -0,0 +1,213 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// storageUnits are the bytes of each storage unit, binary multiples as the commercial system sizes ram and disks
+var storageUnits = map[string]float64{
+	"B":  1,
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+	"TB": 1 << 40,
+}
+
+var storageAliases = map[string]string{
+	"BYTES": "B",
+	"K":     "KB",
+	"KIB":   "KB",
+	"M":     "MB",
+	"MIB":   "MB",
+	"G":     "GB",
+	"GIB":   "GB",
+	"T":     "TB",
+	"TIB":   "TB",
+}
+
+// bandwidthUnits are the bits per second of each bandwidth unit
+var bandwidthUnits = map[string]float64{
+	"bps":  1,
+	"Kbps": 1e3,
+	"Mbps": 1e6,
+	"Gbps": 1e9,
+}
+
+// bandwidthAliases include the storage like units the commercial system sends for rates
+var bandwidthAliases = map[string]string{
+	"BPS":  "bps",
+	"K":    "Kbps",
+	"KB":   "Kbps",
+	"KBPS": "Kbps",
+	"M":    "Mbps",
+	"MB":   "Mbps",
+	"MBPS": "Mbps",
+	"G":    "Gbps",
+	"GB":   "Gbps",
+	"GBPS": "Gbps",
+}
+
+// durationUnits are the months of each publication duration unit
+var durationUnits = map[string]int{
+	"MONTH": 1,
+	"YEAR":  12,
+}
+
+var durationAliases = map[string]string{
+	"M":      "MONTH",
+	"MONTHS": "MONTH",
+	"Y":      "YEAR",
+	"YEARS":  "YEAR",
+}
+
+// NormalizeStorageUnit returns the canonical storage unit, an empty unit is MB
+func NormalizeStorageUnit(unit string) (string, bool) {
+	unit = strings.ToUpper(strings.TrimSpace(unit))
+	if unit == "" {
+		return "MB", true
+	}
+
+	if alias, ok := storageAliases[unit]; ok {
+		unit = alias
+	}
+
+	_, ok := storageUnits[unit]
+
+	return unit, ok
+}
+
+// NormalizeBandwidthUnit returns the canonical bandwidth unit, an empty unit is Mbps
+func NormalizeBandwidthUnit(unit string) (string, bool) {
+	unit = strings.ToUpper(strings.TrimSpace(unit))
+	if unit == "" {
+		return "Mbps", true
+	}
+
+	unit, ok := bandwidthAliases[unit]
+
+	return unit, ok
+}
+
+// NormalizeDurationUnit returns the canonical duration unit, an empty unit is MONTH
+func NormalizeDurationUnit(unit string) (string, bool) {
+	unit = strings.ToUpper(strings.TrimSpace(unit))
+	if unit == "" {
+		return "MONTH", true
+	}
+
+	if alias, ok := durationAliases[unit]; ok {
+		unit = alias
+	}
+
+	_, ok := durationUnits[unit]
+
+	return unit, ok
+}
+
+func StorageBytes(amount float64, unit string) (int64, bool) {
+	unit, ok := NormalizeStorageUnit(unit)
+	if !ok {
+		return 0, false
+	}
+
+	return int64(math.Round(amount * storageUnits[unit])), true
+}
+
+func BandwidthBitsPerSecond(amount float64, unit string) (int64, bool) {
+	unit, ok := NormalizeBandwidthUnit(unit)
+	if !ok {
+		return 0, false
+	}
+
+	return int64(math.Round(amount * bandwidthUnits[unit])), true
+}
+
+func DurationMonths(amount float64, unit string) (int, bool) {
+	unit, ok := NormalizeDurationUnit(unit)
+	if !ok {
+		return 0, false
+	}
+
+	return int(math.Round(amount * float64(durationUnits[unit]))), true
+}
+
+// ConvertStorage converts bytes to the given unit
+func ConvertStorage(bytes int64, unit string) float64 {
+	unit, _ = NormalizeStorageUnit(unit)
+
+	return float64(bytes) / storageUnits[unit]
+}
+
+// ConvertBandwidth converts bits per second to the given unit
+func ConvertBandwidth(bitsPerSecond int64, unit string) float64 {
+	unit, _ = NormalizeBandwidthUnit(unit)
+
+	return float64(bitsPerSecond) / bandwidthUnits[unit]
+}
+
+// ParseStorage parses a quantity such as "4GB" or "512 MB" to bytes, quantities without unit are MB
+func ParseStorage(quantity string) (int64, error) {
+	amount, unit, err := splitQuantity(quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	bytes, ok := StorageBytes(amount, unit)
+	if !ok {
+		return 0, fmt.Errorf("unknown storage unit [%s]", unit)
+	}
+
+	return bytes, nil
+}
+
+// ParseBandwidth parses a quantity such as "100Mbps" or "1 Gbps" to bits per second, quantities without unit are Mbps
+func ParseBandwidth(quantity string) (int64, error) {
+	amount, unit, err := splitQuantity(quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	bitsPerSecond, ok := BandwidthBitsPerSecond(amount, unit)
+	if !ok {
+		return 0, fmt.Errorf("unknown bandwidth unit [%s]", unit)
+	}
+
+	return bitsPerSecond, nil
+}
+
+// ParseDuration parses a duration such as "1YEAR" or "6 months" to months, durations without unit are months
+func ParseDuration(quantity string) (int, error) {
+	amount, unit, err := splitQuantity(quantity)
+	if err != nil {
+		return 0, err
+	}
+
+	months, ok := DurationMonths(amount, unit)
+	if !ok {
+		return 0, fmt.Errorf("unknown duration unit [%s]", unit)
+	}
+
+	return months, nil
+}
+
+func splitQuantity(quantity string) (float64, string, error) {
+	quantity = strings.TrimSpace(quantity)
+
+	i := strings.IndexFunc(quantity, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.'
+	})
+	if i == -1 {
+		i = len(quantity)
+	}
+
+	amount, err := strconv.ParseFloat(quantity[:i], 64)
+	if err != nil || amount < 0 {
+		return 0, "", fmt.Errorf("invalid quantity [%s]", quantity)
+	}
+
+	return amount, quantity[i:], nil
+}
@@ -0,0 +1,128 @@
+package model
+
+import "testing"
+
+func TestParseStorage(t *testing.T) {
+	tests := []struct {
+		quantity string
+		want     int64
+		wantErr  bool
+	}{
+		{quantity: "4GB", want: 4 << 30},
+		{quantity: "512 MB", want: 512 << 20},
+		{quantity: "  2 tb  ", want: 2 << 40},
+		{quantity: "1.5G", want: 3 << 29},
+		{quantity: "8GiB", want: 8 << 30},
+		{quantity: "64k", want: 64 << 10},
+		{quantity: "100bytes", want: 100},
+		{quantity: "256", want: 256 << 20},
+		{quantity: "0", want: 0},
+		{quantity: "-4GB", wantErr: true},
+		{quantity: "GB", wantErr: true},
+		{quantity: "", wantErr: true},
+		{quantity: "4PB", wantErr: true},
+		{quantity: "4Mbps", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseStorage(test.quantity)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseStorage(%q) = %d, want an error", test.quantity, got)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseStorage(%q) error [%s]", test.quantity, err)
+
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("ParseStorage(%q) = %d, want %d", test.quantity, got, test.want)
+		}
+	}
+}
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		quantity string
+		want     int64
+		wantErr  bool
+	}{
+		{quantity: "100Mbps", want: 100e6},
+		{quantity: "1 Gbps", want: 1e9},
+		{quantity: " 512 kbps ", want: 512e3},
+		{quantity: "10MB", want: 10e6},
+		{quantity: "2G", want: 2e9},
+		{quantity: "64bps", want: 64},
+		{quantity: "20", want: 20e6},
+		{quantity: "0.5Mbps", want: 500e3},
+		{quantity: "-10Mbps", wantErr: true},
+		{quantity: "Mbps", wantErr: true},
+		{quantity: "", wantErr: true},
+		{quantity: "10Tbps", wantErr: true},
+		{quantity: "10GiB", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseBandwidth(test.quantity)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseBandwidth(%q) = %d, want an error", test.quantity, got)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseBandwidth(%q) error [%s]", test.quantity, err)
+
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("ParseBandwidth(%q) = %d, want %d", test.quantity, got, test.want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		quantity string
+		want     int
+		wantErr  bool
+	}{
+		{quantity: "12MONTH", want: 12},
+		{quantity: "1YEAR", want: 12},
+		{quantity: " 2 years ", want: 24},
+		{quantity: "6m", want: 6},
+		{quantity: "3", want: 3},
+		{quantity: "-1YEAR", wantErr: true},
+		{quantity: "10DAY", wantErr: true},
+		{quantity: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseDuration(test.quantity)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("ParseDuration(%q) = %d, want an error", test.quantity, got)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseDuration(%q) error [%s]", test.quantity, err)
+
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("ParseDuration(%q) = %d, want %d", test.quantity, got, test.want)
+		}
+	}
+}
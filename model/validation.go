@@ -0,0 +1,19 @@
+package model
+
+type OfferValidation struct {
+	ExternalID  string        `json:"external_id"`
+	Name        string        `json:"name"`
+	Primary     bool          `json:"primary"`
+	Action      SyncAction    `json:"action"`
+	Offer       *Offer        `json:"offer,omitempty"`
+	Changes     []FieldChange `json:"changes,omitempty"`
+	Diagnostics []Diagnostic  `json:"diagnostics,omitempty"`
+	Errors      []string      `json:"errors,omitempty"`
+
+	Violations []AttributeViolation `json:"violations,omitempty"`
+}
+
+type SyncValidation struct {
+	Valid  bool              `json:"valid"`
+	Offers []OfferValidation `json:"offers"`
+}
@@ -0,0 +1,15 @@
+package model
+
+type ViolationRule string
+
+const (
+	RequiredViolationRule  ViolationRule = "REQUIRED"
+	ForbiddenViolationRule ViolationRule = "FORBIDDEN"
+)
+
+// AttributeViolation is a data center attribute missing from or not allowed on the offer type
+type AttributeViolation struct {
+	Attribute string        `json:"attribute"`
+	Rule      ViolationRule `json:"rule"`
+	Message   string        `json:"message"`
+}
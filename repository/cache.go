@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// generationKey is the key of the document counting the invalidations, it has no expiration so Get never returns it
+const generationKey = "generation"
+
+func NewCacheRepository(client *mongo.Client, database string, table string) *cacheRepository {
+	return &cacheRepository{
+		collection: client.Database(database).Collection(table),
+	}
+}
+
+// Get returns the entry unless it is missing or expired, the TTL index only removes expired entries periodically
+func (r *cacheRepository) Get(ctx context.Context, key string) (*model.CacheEntry, error) {
+	var entry model.CacheEntry
+
+	filter := bson.D{{"_id", key}, {"expires_at", bson.D{{"$gt", time.Now()}}}}
+
+	err := r.collection.FindOne(ctx, filter).Decode(&entry)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (r *cacheRepository) Set(ctx context.Context, entry model.CacheEntry) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.D{{"_id", entry.Key}}, entry, options.Replace().SetUpsert(true))
+
+	return err
+}
+
+// RemoveTags removes the entries stored with any of the tags and increases the generation
+func (r *cacheRepository) RemoveTags(ctx context.Context, tags []string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.D{{"tags", bson.D{{"$in", tags}}}})
+	if err != nil {
+		return err
+	}
+
+	return r.increaseGeneration(ctx)
+}
+
+// Clear removes every entry and increases the generation
+func (r *cacheRepository) Clear(ctx context.Context) error {
+	_, err := r.collection.DeleteMany(ctx, bson.D{{"_id", bson.D{{"$ne", generationKey}}}})
+	if err != nil {
+		return err
+	}
+
+	return r.increaseGeneration(ctx)
+}
+
+// Generation returns how many times the entries were removed, instances compare it to know when another one
+// invalidated the reads they keep in memory
+func (r *cacheRepository) Generation(ctx context.Context) (int64, error) {
+	var generation struct {
+		Value int64 `bson:"value"`
+	}
+
+	err := r.collection.FindOne(ctx, bson.D{{"_id", generationKey}}).Decode(&generation)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	return generation.Value, nil
+}
+
+func (r *cacheRepository) increaseGeneration(ctx context.Context) error {
+	_, err := r.collection.UpdateOne(ctx, bson.D{{"_id", generationKey}},
+		bson.D{{"$inc", bson.D{{"value", int64(1)}}}}, options.Update().SetUpsert(true))
+
+	return err
+}
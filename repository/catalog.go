@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewCatalogRepository(client *mongo.Client, database string, table string) *catalogRepository {
+	return &catalogRepository{
+		collection: client.Database(database).Collection(table),
+	}
+}
+
+func (r *catalogRepository) All(ctx context.Context) ([]model.Catalog, error) {
+	cursor, err := r.collection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{"name", 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	catalogs := make([]model.Catalog, 0)
+
+	for cursor.Next(ctx) {
+		var catalog model.Catalog
+
+		err = cursor.Decode(&catalog)
+		if err != nil {
+			return nil, err
+		}
+
+		catalogs = append(catalogs, catalog)
+	}
+
+	return catalogs, nil
+}
+
+func (r *catalogRepository) Get(ctx context.Context, id string) (*model.Catalog, error) {
+	var catalog model.Catalog
+
+	err := r.collection.FindOne(ctx, bson.D{{"_id", id}}).Decode(&catalog)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &catalog, nil
+}
+
+func (r *catalogRepository) Upsert(ctx context.Context, catalog model.Catalog) (*model.Catalog, error) {
+	now := time.Now().Format("2006-01-02 15:04:00")
+
+	catalog.UpdatedAt = now
+
+	upsert := true
+
+	_, err := r.collection.UpdateOne(ctx, bson.D{{"_id", catalog.ID}},
+		bson.D{
+			{"$set", bson.D{{"name", catalog.Name}, {"updated_at", catalog.UpdatedAt}}},
+			{"$setOnInsert", bson.D{{"created_at", now}}},
+		}, &options.UpdateOptions{
+			Upsert: &upsert,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return &catalog, nil
+}
@@ -0,0 +1,463 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The conformance suite runs the same cases on every offer repository backend so the memory and sql ones keep the
+// semantics of the mongo one. The mongo backend runs when OFFERS_TEST_MONGO_URI points to a server
+var backends = map[string]func(t *testing.T) OfferRepository{
+	"memory": func(t *testing.T) OfferRepository {
+		return NewMemoryRepository()
+	},
+	"sqlite": func(t *testing.T) OfferRepository {
+		db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "offers.db"))
+		if err != nil {
+			t.Fatalf("opening sqlite error [%s]", err)
+		}
+
+		t.Cleanup(func() { db.Close() })
+
+		r := NewSQLRepository(db, "sqlite3", "offers")
+
+		if err = r.CreateTable(context.Background()); err != nil {
+			t.Skipf("sqlite is not available [%s]", err)
+		}
+
+		return r
+	},
+	"mongo": func(t *testing.T) OfferRepository {
+		uri := os.Getenv("OFFERS_TEST_MONGO_URI")
+		if uri == "" {
+			t.Skip("OFFERS_TEST_MONGO_URI is not set")
+		}
+
+		ctx := context.Background()
+
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+		if err != nil {
+			t.Fatalf("connecting mongo error [%s]", err)
+		}
+
+		database := fmt.Sprintf("offers_conformance_%d", time.Now().UnixNano())
+
+		t.Cleanup(func() {
+			client.Database(database).Drop(ctx)
+			client.Disconnect(ctx)
+		})
+
+		collection := client.Database(database).Collection("offers")
+
+		_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{"external_id", 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.D{{"external_id", bson.D{{"$type", "string"}}}}),
+		})
+		if err != nil {
+			t.Fatalf("creating index error [%s]", err)
+		}
+
+		return NewRepository(client, database, "offers")
+	},
+}
+
+func runConformance(t *testing.T, test func(t *testing.T, r OfferRepository)) {
+	for name, backend := range backends {
+		backend := backend
+
+		t.Run(name, func(t *testing.T) {
+			test(t, backend(t))
+		})
+	}
+}
+
+func stringPtr(v string) *string {
+	return &v
+}
+
+func externalIDs(offers []model.Offer) []string {
+	ids := make([]string, 0, len(offers))
+
+	for _, offer := range offers {
+		if offer.ExternalID != nil {
+			ids = append(ids, *offer.ExternalID)
+		}
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+func equalIDs(t *testing.T, name string, offers []model.Offer, want ...string) {
+	t.Helper()
+
+	got := externalIDs(offers)
+
+	if len(got) != len(want) {
+		t.Errorf("%s = %v, want %v", name, got, want)
+
+		return
+	}
+
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("%s = %v, want %v", name, got, want)
+
+			return
+		}
+	}
+}
+
+func TestConformanceUpsert(t *testing.T) {
+	runConformance(t, func(t *testing.T, r OfferRepository) {
+		ctx := context.Background()
+
+		offer, err := r.Upsert(ctx, model.Offer{ExternalID: stringPtr("a"), Name: "A"})
+		if err != nil {
+			t.Fatalf("upsert error [%s]", err)
+		}
+
+		if offer.ID == "" || offer.Version != 1 || offer.CreatedAt == "" {
+			t.Fatalf("upserted offer = %+v, want an id, version 1 and creation date", offer)
+		}
+
+		offer.Name = "A2"
+
+		updated, err := r.Upsert(ctx, *offer)
+		if err != nil {
+			t.Fatalf("update error [%s]", err)
+		}
+
+		if updated.Version != 2 {
+			t.Errorf("version = %d, want 2", updated.Version)
+		}
+
+		if _, err = r.Upsert(ctx, *offer); !errors.Is(err, ErrVersionConflict) {
+			t.Errorf("stale upsert error [%v], want a version conflict", err)
+		}
+
+		stored, err := r.Get(ctx, "a")
+		if err != nil {
+			t.Fatalf("get error [%s]", err)
+		}
+
+		if stored == nil || stored.Name != "A2" || stored.ID != offer.ID || stored.Version != 2 {
+			t.Errorf("stored offer = %+v, want A2 on version 2", stored)
+		}
+	})
+}
+
+func TestConformanceUpsertByExternalID(t *testing.T) {
+	runConformance(t, func(t *testing.T, r OfferRepository) {
+		ctx := context.Background()
+
+		created, err := r.UpsertByExternalID(ctx, model.Offer{ExternalID: stringPtr("a"), Name: "A"})
+		if err != nil {
+			t.Fatalf("upsert error [%s]", err)
+		}
+
+		if created.ID == "" || created.Version != 1 {
+			t.Fatalf("created offer = %+v, want an id on version 1", created)
+		}
+
+		updated, err := r.UpsertByExternalID(ctx, model.Offer{
+			ID:         "ignored",
+			ExternalID: stringPtr("a"),
+			Name:       "A2",
+			Version:    1,
+		})
+		if err != nil {
+			t.Fatalf("update error [%s]", err)
+		}
+
+		if updated.ID != created.ID || updated.CreatedAt != created.CreatedAt || updated.Version != 2 ||
+			updated.Name != "A2" {
+			t.Errorf("updated offer = %+v, want the id and creation date of %+v on version 2", updated, created)
+		}
+
+		_, err = r.UpsertByExternalID(ctx, model.Offer{ExternalID: stringPtr("a"), Name: "A3", Version: 1})
+		if !errors.Is(err, ErrVersionConflict) {
+			t.Errorf("stale upsert error [%v], want a version conflict", err)
+		}
+	})
+}
+
+func TestConformanceUpsertUnsetsDroppedFields(t *testing.T) {
+	runConformance(t, func(t *testing.T, r OfferRepository) {
+		ctx := context.Background()
+
+		created, err := r.UpsertByExternalID(ctx, model.Offer{
+			ExternalID: stringPtr("a"),
+			Name:       "A",
+			Catalogs:   []string{"web"},
+			References: []model.SupplementaryReference{{ID: "1", ExternalID: "extra", Type: model.OptionalRelationType}},
+		})
+		if err != nil {
+			t.Fatalf("upsert error [%s]", err)
+		}
+
+		updated, err := r.UpsertByExternalID(ctx, model.Offer{ExternalID: stringPtr("a"), Name: "A", Version: 1})
+		if err != nil {
+			t.Fatalf("upsert error [%s]", err)
+		}
+
+		if len(updated.Catalogs) > 0 || len(updated.References) > 0 || updated.CreatedAt != created.CreatedAt {
+			t.Errorf("updated offer = %+v, want no catalogs nor references and the creation date kept", updated)
+		}
+
+		updated.Name = ""
+
+		if _, err = r.Upsert(ctx, *updated); err != nil {
+			t.Fatalf("upsert error [%s]", err)
+		}
+
+		stored, err := r.Get(ctx, "a")
+		if err != nil {
+			t.Fatalf("get error [%s]", err)
+		}
+
+		if stored.Name != "" || len(stored.Catalogs) > 0 || stored.CreatedAt != created.CreatedAt {
+			t.Errorf("stored offer = %+v, want the name unset and the creation date kept", stored)
+		}
+	})
+}
+
+func TestConformanceCreatePending(t *testing.T) {
+	runConformance(t, func(t *testing.T, r OfferRepository) {
+		ctx := context.Background()
+
+		pending, err := r.CreatePending(ctx, "s")
+		if err != nil {
+			t.Fatalf("create pending error [%s]", err)
+		}
+
+		if !pending.Pending || pending.ID == "" || pending.Version != 1 {
+			t.Fatalf("pending offer = %+v, want a pending placeholder on version 1", pending)
+		}
+
+		again, err := r.CreatePending(ctx, "s")
+		if err != nil {
+			t.Fatalf("create pending error [%s]", err)
+		}
+
+		if again.ID != pending.ID {
+			t.Errorf("second placeholder id = %s, want the stored %s", again.ID, pending.ID)
+		}
+
+		if _, err = r.UpsertByExternalID(ctx, model.Offer{ExternalID: stringPtr("s"), Name: "S", Version: 1}); err != nil {
+			t.Fatalf("upsert error [%s]", err)
+		}
+
+		synced, err := r.CreatePending(ctx, "s")
+		if err != nil {
+			t.Fatalf("create pending error [%s]", err)
+		}
+
+		if synced.Name != "S" || synced.ID != pending.ID {
+			t.Errorf("offer = %+v, want the synced S", synced)
+		}
+	})
+}
+
+func TestConformanceGetAndRemove(t *testing.T) {
+	runConformance(t, func(t *testing.T, r OfferRepository) {
+		ctx := context.Background()
+
+		offer, err := r.Get(ctx, "missing")
+		if err != nil || offer != nil {
+			t.Errorf("get missing = %+v [%v], want nil", offer, err)
+		}
+
+		for _, id := range []string{"a", "b"} {
+			if _, err = r.UpsertByExternalID(ctx, model.Offer{ExternalID: stringPtr(id), Name: id}); err != nil {
+				t.Fatalf("upsert error [%s]", err)
+			}
+		}
+
+		if err = r.RemoveByExternalID(ctx, "a"); err != nil {
+			t.Fatalf("remove error [%s]", err)
+		}
+
+		if err = r.RemoveByExternalID(ctx, "missing"); err != nil {
+			t.Errorf("remove missing error [%s]", err)
+		}
+
+		if offer, err = r.GetByExternalID(ctx, "a"); err != nil || offer != nil {
+			t.Errorf("get removed = %+v [%v], want nil", offer, err)
+		}
+
+		offers, err := r.All(ctx)
+		if err != nil {
+			t.Fatalf("all error [%s]", err)
+		}
+
+		equalIDs(t, "all", offers, "b")
+	})
+}
+
+func TestConformanceSearch(t *testing.T) {
+	runConformance(t, func(t *testing.T, r OfferRepository) {
+		ctx := context.Background()
+
+		dataCenter := model.CategoryTypeDataCenter
+		yellowPages := model.CategoryTypeYellowPages
+
+		offers := []model.Offer{
+			{
+				ExternalID: stringPtr("small"),
+				Name:       "small",
+				Category:   dataCenter,
+				DataCenterResourceAttributtes: &model.DataCenterResourceAttributtes{
+					RAM: &model.RAM{Amount: 2, Unit: "GB", Bytes: 2 << 30},
+				},
+				EffectiveAt:  time.Now().Add(-time.Hour).Unix(),
+				ExpirationAt: time.Now().Add(time.Hour).Unix(),
+			},
+			{
+				ExternalID:   stringPtr("expired"),
+				Name:         "expired",
+				EffectiveAt:  time.Now().Add(-2 * time.Hour).Unix(),
+				ExpirationAt: time.Now().Add(-time.Hour).Unix(),
+			},
+			{
+				ExternalID:  stringPtr("future"),
+				Name:        "future",
+				EffectiveAt: time.Now().Add(time.Hour).Unix(),
+			},
+			{
+				ExternalID: stringPtr("large"),
+				Name:       "large",
+				Category:   dataCenter,
+				DataCenterResourceAttributtes: &model.DataCenterResourceAttributtes{
+					RAM: &model.RAM{Amount: 16, Unit: "GB", Bytes: 16 << 30},
+				},
+			},
+			{
+				ExternalID: stringPtr("listing"),
+				Name:       "listing",
+				Category:   yellowPages,
+				YellowPagesAttributes: &model.YellowPagesAttributes{
+					ListingTier: "GOLD",
+					Regions:     []string{"HAVANA", "MATANZAS"},
+					Months:      12,
+				},
+			},
+		}
+
+		for _, offer := range offers {
+			if _, err := r.UpsertByExternalID(ctx, offer); err != nil {
+				t.Fatalf("upsert error [%s]", err)
+			}
+		}
+
+		min, max := int64(4<<30), int64(8<<30)
+		months, longer := 12, 13
+		active, inactive := true, false
+
+		tests := []struct {
+			name     string
+			active   *bool
+			category *model.CategoryType
+			filters  model.SearchFilters
+			want     []string
+		}{
+			{name: "everything", want: []string{"expired", "future", "large", "listing", "small"}},
+			{name: "category", category: &dataCenter, want: []string{"large", "small"}},
+			{name: "ram min", filters: model.SearchFilters{RAMMin: &min}, want: []string{"large"}},
+			{name: "ram range", filters: model.SearchFilters{RAMMin: &min, RAMMax: &max}},
+			{name: "ram max skips offers without ram", filters: model.SearchFilters{RAMMax: &max}, want: []string{"small"}},
+			{name: "region", filters: model.SearchFilters{Region: "MATANZAS"}, want: []string{"listing"}},
+			{name: "listing tier", filters: model.SearchFilters{ListingTier: "SILVER"}},
+			{name: "duration", filters: model.SearchFilters{DurationMin: &months}, want: []string{"listing"}},
+			{name: "longer duration", filters: model.SearchFilters{DurationMin: &longer}},
+			// offers without dates are unbounded
+			{name: "active", active: &active, want: []string{"large", "listing", "small"}},
+			{name: "inactive", active: &inactive, want: []string{"expired", "future"}},
+			{name: "active category", active: &active, category: &yellowPages, want: []string{"listing"}},
+			{name: "active ram", active: &active, filters: model.SearchFilters{RAMMax: &max}, want: []string{"small"}},
+		}
+
+		for _, test := range tests {
+			found, err := r.Search(ctx, test.active, test.category, test.filters)
+			if err != nil {
+				t.Fatalf("%s search error [%s]", test.name, err)
+			}
+
+			equalIDs(t, test.name, found, test.want...)
+		}
+	})
+}
+
+func TestConformanceLookups(t *testing.T) {
+	runConformance(t, func(t *testing.T, r OfferRepository) {
+		ctx := context.Background()
+
+		primary, err := r.UpsertByExternalID(ctx, model.Offer{
+			ExternalID: stringPtr("primary"),
+			Name:       "primary",
+			Catalogs:   []string{"web"},
+			References: []model.SupplementaryReference{
+				{ID: "1", ExternalID: "extra", Type: model.OptionalRelationType},
+				{ID: "2", ExternalID: "backup", Type: model.MandatoryRelationType},
+			},
+		})
+		if err != nil {
+			t.Fatalf("upsert error [%s]", err)
+		}
+
+		if _, err = r.UpsertByExternalID(ctx, model.Offer{ExternalID: stringPtr("other"), Name: "other"}); err != nil {
+			t.Fatalf("upsert error [%s]", err)
+		}
+
+		if _, err = r.CreatePending(ctx, "pending"); err != nil {
+			t.Fatalf("create pending error [%s]", err)
+		}
+
+		byIDs, err := r.GetByIDList(ctx, []string{primary.ID, "other", "pending"})
+		if err != nil {
+			t.Fatalf("get by ids error [%s]", err)
+		}
+
+		equalIDs(t, "by id list", byIDs, "other", "primary")
+
+		if none, err := r.GetByIDList(ctx, []string{"missing"}); err != nil || none != nil {
+			t.Errorf("get by missing ids = %v [%v], want nil", none, err)
+		}
+
+		bySupplementary, err := r.GetBySupplementary(ctx, "extra", nil)
+		if err != nil {
+			t.Fatalf("get by supplementary error [%s]", err)
+		}
+
+		equalIDs(t, "by supplementary", bySupplementary, "primary")
+
+		byRelation, err := r.GetBySupplementary(ctx, "extra", []model.RelationType{model.MandatoryRelationType})
+		if err != nil {
+			t.Fatalf("get by supplementary error [%s]", err)
+		}
+
+		equalIDs(t, "by supplementary relation", byRelation)
+
+		byCatalog, err := r.GetByCatalog(ctx, "web")
+		if err != nil {
+			t.Fatalf("get by catalog error [%s]", err)
+		}
+
+		equalIDs(t, "by catalog", byCatalog, "primary")
+	})
+}
@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// The memory and sql offer repositories keep the offers as the documents the mongo repository stores and apply the
+// same $set, $unset and query semantics on them, so every backend behaves as the mongo one
+
+// offerFields are the top level fields of the offer documents
+var offerFields = bsonFields(reflect.TypeOf(model.Offer{}))
+
+// keptFields are never unset, an upsert keeps the id and creation date of the stored document
+var keptFields = map[string]bool{
+	"_id":        true,
+	"created_at": true,
+}
+
+func bsonFields(t reflect.Type) []string {
+	fields := make([]string, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("bson"), ",")[0]
+		if name == "" {
+			name = strings.ToLower(t.Field(i).Name)
+		}
+
+		if name != "-" {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}
+
+// unsetFields returns the $unset of the offer fields missing from the fields set, omitempty leaves out the fields the
+// offer no longer has and a $set alone would keep their stored value
+func unsetFields(fields bson.D) bson.D {
+	unset := bson.D{}
+
+	for _, name := range offerFields {
+		if !keptFields[name] && !hasField(fields, name) {
+			unset = append(unset, bson.E{name, ""})
+		}
+	}
+
+	return unset
+}
+
+func hasField(document bson.D, key string) bool {
+	for _, field := range document {
+		if field.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func offerDocument(offer model.Offer) (bson.D, error) {
+	raw, err := bson.Marshal(offer)
+	if err != nil {
+		return nil, err
+	}
+
+	var document bson.D
+
+	if err = bson.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+func decodeOffer(document bson.D) (*model.Offer, error) {
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var offer model.Offer
+
+	if err = bson.Unmarshal(raw, &offer); err != nil {
+		return nil, err
+	}
+
+	return &offer, nil
+}
+
+// setDocument applies a $set of the fields and the $unset of the offer fields left out on the stored document, as
+// the upserts of the mongo repository do
+func setDocument(stored bson.D, fields bson.D) bson.D {
+	unset := unsetFields(fields)
+
+	document := make(bson.D, 0, len(stored)+len(fields))
+
+	for _, field := range stored {
+		if !hasField(unset, field.Key) {
+			document = append(document, field)
+		}
+	}
+
+	for _, field := range fields {
+		set := false
+
+		for i := range document {
+			if document[i].Key == field.Key {
+				document[i].Value = field.Value
+				set = true
+
+				break
+			}
+		}
+
+		if !set {
+			document = append(document, field)
+		}
+	}
+
+	return document
+}
+
+// externalIDUpdate returns the fields UpsertByExternalID sets on the document and the ones it only sets when
+// inserting it, the id and creation date of an existing document are kept
+func externalIDUpdate(offer model.Offer) (bson.D, bson.D, error) {
+	fields, err := offerDocument(offer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:00")
+
+	set := bson.D{}
+
+	for _, field := range fields {
+		if field.Key == "_id" || field.Key == "created_at" || field.Key == "updated_at" {
+			continue
+		}
+
+		set = append(set, field)
+	}
+
+	set = append(set, bson.E{"updated_at", now})
+
+	id := offer.ID
+	if id == "" {
+		id = uuid.NewString()
+	}
+
+	return set, bson.D{{"_id", id}, {"created_at", now}}, nil
+}
+
+// externalIDDocument applies the UpsertByExternalID update on the stored document, stored is nil to insert one
+func externalIDDocument(stored bson.D, offer model.Offer) (bson.D, error) {
+	set, setOnInsert, err := externalIDUpdate(offer)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored == nil {
+		stored = setOnInsert
+	}
+
+	return setDocument(stored, set), nil
+}
+
+func documentID(document bson.D) string {
+	for _, field := range document {
+		if field.Key == "_id" {
+			id, _ := field.Value.(string)
+
+			return id
+		}
+	}
+
+	return ""
+}
+
+// documentVersion returns the version of the document, documents stored before versioning are on version 0
+func documentVersion(document bson.D) int64 {
+	for _, field := range document {
+		if field.Key != "version" {
+			continue
+		}
+
+		switch version := field.Value.(type) {
+		case int64:
+			return version
+		case int32:
+			return int64(version)
+		}
+	}
+
+	return 0
+}
+
+func pendingOffer(externalID string) model.Offer {
+	now := time.Now().Format("2006-01-02 15:04:00")
+
+	return model.Offer{
+		ID:         uuid.NewString(),
+		ExternalID: &externalID,
+		Pending:    true,
+		Version:    1,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+func initializeOffer(offer *model.Offer) {
+	now := time.Now().Format("2006-01-02 15:04:00")
+
+	if offer.ID == "" {
+		offer.ID = uuid.NewString()
+		offer.CreatedAt = now
+	}
+
+	offer.UpdatedAt = now
+}
+
+// searchMatches is the Search query of the mongo repository at the unix time now
+func searchMatches(offer model.Offer, now int64, active *bool, category *model.CategoryType,
+	filters model.SearchFilters,
+) bool {
+	if active != nil && isActive(offer, now) != *active {
+		return false
+	}
+
+	if category != nil && offer.Category != *category {
+		return false
+	}
+
+	var ram, hdd, database, bandwidth int64
+
+	if attributes := offer.DataCenterResourceAttributtes; attributes != nil {
+		if attributes.RAM != nil {
+			ram = attributes.RAM.Bytes
+		}
+
+		if attributes.HDD != nil {
+			hdd = attributes.HDD.Bytes
+		}
+
+		if attributes.Database != nil {
+			database = attributes.Database.Bytes
+		}
+
+		if attributes.Bandwidth != nil {
+			bandwidth = attributes.Bandwidth.BitsPerSecond
+		}
+	}
+
+	if !inRange(ram, filters.RAMMin, filters.RAMMax) || !inRange(hdd, filters.HDDMin, filters.HDDMax) ||
+		!inRange(database, filters.DatabaseMin, filters.DatabaseMax) ||
+		!inRange(bandwidth, filters.BandwidthMin, filters.BandwidthMax) {
+		return false
+	}
+
+	if filters.ListingTier == "" && filters.AdSize == "" && filters.Region == "" && filters.DurationMin == nil {
+		return true
+	}
+
+	attributes := offer.YellowPagesAttributes
+	if attributes == nil {
+		return false
+	}
+
+	if filters.ListingTier != "" && attributes.ListingTier != filters.ListingTier {
+		return false
+	}
+
+	if filters.AdSize != "" && attributes.AdSize != filters.AdSize {
+		return false
+	}
+
+	if filters.Region != "" && !contains(attributes.Regions, filters.Region) {
+		return false
+	}
+
+	if filters.DurationMin != nil && (attributes.Months == 0 || attributes.Months < *filters.DurationMin) {
+		return false
+	}
+
+	return true
+}
+
+// isActive mirrors the active query, an offer without a date has no bound on that side
+func isActive(offer model.Offer, now int64) bool {
+	return (offer.EffectiveAt == 0 || offer.EffectiveAt <= now) && (offer.ExpirationAt == 0 || offer.ExpirationAt >= now)
+}
+
+// inRange mirrors rangeFilter, a zero value is omitted from the document so it never matches a range
+func inRange(value int64, min *int64, max *int64) bool {
+	if min == nil && max == nil {
+		return true
+	}
+
+	if value == 0 {
+		return false
+	}
+
+	return (min == nil || value >= *min) && (max == nil || value <= *max)
+}
+
+// idListMatches is the GetByIDList query of the mongo repository
+func idListMatches(offer model.Offer, ids []string) bool {
+	if offer.Pending || offer.Name == "" {
+		return false
+	}
+
+	return contains(ids, offer.ID) || offer.ExternalID != nil && contains(ids, *offer.ExternalID)
+}
+
+// supplementaryMatches is the GetBySupplementary query of the mongo repository
+func supplementaryMatches(offer model.Offer, externalID string, relationTypes []model.RelationType) bool {
+	for _, reference := range offer.References {
+		if reference.ExternalID != externalID {
+			continue
+		}
+
+		if len(relationTypes) == 0 {
+			return true
+		}
+
+		for _, relationType := range relationTypes {
+			if reference.Type == relationType {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
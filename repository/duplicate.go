@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type duplicateDocument struct {
+	ID        string `bson:"_id"`
+	Name      string `bson:"name"`
+	Pending   bool   `bson:"pending"`
+	UpdatedAt string `bson:"updated_at"`
+}
+
+type duplicateGroup struct {
+	ExternalID string              `bson:"_id"`
+	Documents  []duplicateDocument `bson:"documents"`
+}
+
+// MergeDuplicates keeps one document per external id in the offer collections, preferring synced ones with a name
+// and the latest update, references to the removed documents are moved to the kept one before removing them
+func (m *migrator) MergeDuplicates(ctx context.Context, dryRun bool) (*model.DuplicateReport, error) {
+	report := model.DuplicateReport{
+		DryRun: dryRun,
+		Groups: make([]model.DuplicateGroup, 0),
+	}
+
+	for _, collection := range []*mongo.Collection{m.collections.Offers, m.collections.Supplementaries} {
+		groups, err := duplicateGroups(ctx, collection)
+		if err != nil {
+			return nil, fmt.Errorf("collection [%s] [%w]", collection.Name(), err)
+		}
+
+		for _, group := range groups {
+			kept, removed := keptDocument(group.Documents)
+
+			report.Groups = append(report.Groups, model.DuplicateGroup{
+				Collection: collection.Name(),
+				ExternalID: group.ExternalID,
+				KeptID:     kept,
+				RemovedIDs: removed,
+			})
+
+			if dryRun {
+				continue
+			}
+
+			if err = m.relink(ctx, kept, removed); err != nil {
+				return nil, fmt.Errorf("external id [%s] [%w]", group.ExternalID, err)
+			}
+
+			if _, err = collection.DeleteMany(ctx, bson.D{{"_id", bson.D{{"$in", removed}}}}); err != nil {
+				return nil, fmt.Errorf("external id [%s] [%w]", group.ExternalID, err)
+			}
+		}
+	}
+
+	return &report, nil
+}
+
+func duplicateGroups(ctx context.Context, collection *mongo.Collection) ([]duplicateGroup, error) {
+	pipeline := mongo.Pipeline{
+		{{"$match", bson.D{{"external_id", bson.D{{"$type", "string"}}}}}},
+		{{"$group", bson.D{
+			{"_id", "$external_id"},
+			{"documents", bson.D{{"$push", bson.D{
+				{"_id", "$_id"},
+				{"name", "$name"},
+				{"pending", "$pending"},
+				{"updated_at", "$updated_at"},
+			}}}},
+			{"count", bson.D{{"$sum", 1}}},
+		}}},
+		{{"$match", bson.D{{"count", bson.D{{"$gt", 1}}}}}},
+	}
+
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	groups := make([]duplicateGroup, 0)
+
+	if err = cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+func keptDocument(documents []duplicateDocument) (string, []string) {
+	sort.SliceStable(documents, func(i, j int) bool {
+		if documents[i].Pending != documents[j].Pending {
+			return !documents[i].Pending
+		}
+
+		if (documents[i].Name == "") != (documents[j].Name == "") {
+			return documents[i].Name != ""
+		}
+
+		return documents[i].UpdatedAt > documents[j].UpdatedAt
+	})
+
+	removed := make([]string, 0, len(documents)-1)
+
+	for _, document := range documents[1:] {
+		removed = append(removed, document.ID)
+	}
+
+	return documents[0].ID, removed
+}
+
+// relink points the supplementary ids, relationships and bundle components of every offer at the kept document
+func (m *migrator) relink(ctx context.Context, kept string, removed []string) error {
+	for _, collection := range []*mongo.Collection{m.collections.Offers, m.collections.Supplementaries} {
+		for _, id := range removed {
+			updates := []struct {
+				field       string
+				filter      string
+				arrayFilter bson.D
+			}{
+				{"supplementaries.$[s]", "supplementaries", bson.D{{"s", id}}},
+				{"references.$[r].id", "references.id", bson.D{{"r.id", id}}},
+				{"components.$[c].id", "components.id", bson.D{{"c.id", id}}},
+			}
+
+			for _, update := range updates {
+				opts := options.Update().SetArrayFilters(options.ArrayFilters{
+					Filters: []interface{}{update.arrayFilter},
+				})
+
+				_, err := collection.UpdateMany(ctx, bson.D{{update.filter, id}},
+					bson.D{{"$set", bson.D{{update.field, kept}}}, {"$inc", bson.D{{"version", 1}}}}, opts)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
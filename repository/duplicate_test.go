@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestKeptDocument(t *testing.T) {
+	tests := []struct {
+		name        string
+		documents   []duplicateDocument
+		wantKept    string
+		wantRemoved []string
+	}{
+		{
+			name: "synced over pending",
+			documents: []duplicateDocument{
+				{ID: "pending", Pending: true, UpdatedAt: "2024-02-01 00:00:00"},
+				{ID: "synced", Name: "A", UpdatedAt: "2024-01-01 00:00:00"},
+			},
+			wantKept:    "synced",
+			wantRemoved: []string{"pending"},
+		},
+		{
+			name: "named over unnamed",
+			documents: []duplicateDocument{
+				{ID: "unnamed", UpdatedAt: "2024-02-01 00:00:00"},
+				{ID: "named", Name: "A", UpdatedAt: "2024-01-01 00:00:00"},
+			},
+			wantKept:    "named",
+			wantRemoved: []string{"unnamed"},
+		},
+		{
+			name: "latest update",
+			documents: []duplicateDocument{
+				{ID: "old", Name: "A", UpdatedAt: "2024-01-01 00:00:00"},
+				{ID: "new", Name: "A", UpdatedAt: "2024-03-01 00:00:00"},
+				{ID: "middle", Name: "A", UpdatedAt: "2024-02-01 00:00:00"},
+			},
+			wantKept:    "new",
+			wantRemoved: []string{"middle", "old"},
+		},
+		{
+			name: "ties keep the first",
+			documents: []duplicateDocument{
+				{ID: "first", Name: "A", UpdatedAt: "2024-01-01 00:00:00"},
+				{ID: "second", Name: "A", UpdatedAt: "2024-01-01 00:00:00"},
+			},
+			wantKept:    "first",
+			wantRemoved: []string{"second"},
+		},
+	}
+
+	for _, test := range tests {
+		kept, removed := keptDocument(test.documents)
+
+		if kept != test.wantKept || !reflect.DeepEqual(removed, test.wantRemoved) {
+			t.Errorf("%s kept %s and removed %v, want %s and %v", test.name, kept, removed, test.wantKept,
+				test.wantRemoved)
+		}
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	ctx := context.Background()
+
+	m := testMigrator(t)
+
+	offers := []bson.D{
+		{{"_id", "a-old"}, {"external_id", "a"}, {"name", "A"}, {"updated_at", "2024-01-01 00:00:00"}},
+		{{"_id", "a-new"}, {"external_id", "a"}, {"name", "A"}, {"updated_at", "2024-02-01 00:00:00"}, {"version", int64(1)},
+			{"supplementaries", bson.A{"s-pending"}},
+			{"references", bson.A{bson.D{{"id", "s-pending"}, {"external_id", "s"}, {"type", "OPTIONAL"}}}}},
+		{{"_id", "b"}, {"external_id", "b"}, {"name", "B"}, {"version", int64(1)},
+			{"components", bson.A{bson.D{{"id", "a-old"}, {"external_id", "a"}}}}},
+	}
+
+	supplementaries := []bson.D{
+		{{"_id", "s-pending"}, {"external_id", "s"}, {"pending", true}, {"updated_at", "2024-03-01 00:00:00"}},
+		{{"_id", "s-synced"}, {"external_id", "s"}, {"name", "S"}, {"updated_at", "2024-01-01 00:00:00"}},
+	}
+
+	for _, document := range offers {
+		if _, err := m.collections.Offers.InsertOne(ctx, document); err != nil {
+			t.Fatalf("insert error [%s]", err)
+		}
+	}
+
+	for _, document := range supplementaries {
+		if _, err := m.collections.Supplementaries.InsertOne(ctx, document); err != nil {
+			t.Fatalf("insert error [%s]", err)
+		}
+	}
+
+	report, err := m.MergeDuplicates(ctx, true)
+	if err != nil {
+		t.Fatalf("dry run error [%s]", err)
+	}
+
+	if len(report.Groups) != 2 {
+		t.Fatalf("dry run groups = %+v, want a and s", report.Groups)
+	}
+
+	if count, _ := m.collections.Offers.CountDocuments(ctx, bson.D{}); count != 3 {
+		t.Fatalf("offers after a dry run = %d, want 3", count)
+	}
+
+	if report, err = m.MergeDuplicates(ctx, false); err != nil {
+		t.Fatalf("merge error [%s]", err)
+	}
+
+	kept := make(map[string]string, len(report.Groups))
+
+	for _, group := range report.Groups {
+		kept[group.ExternalID] = group.KeptID
+	}
+
+	if kept["a"] != "a-new" || kept["s"] != "s-synced" {
+		t.Errorf("kept = %v, want a-new and s-synced", kept)
+	}
+
+	for collection, want := range map[string]int64{"offers": 2, "supplementaries": 1} {
+		c := m.collections.Offers
+		if collection == "supplementaries" {
+			c = m.collections.Supplementaries
+		}
+
+		if count, _ := c.CountDocuments(ctx, bson.D{}); count != want {
+			t.Errorf("%s after merging = %d, want %d", collection, count, want)
+		}
+	}
+
+	var primary struct {
+		Supplementaries []string `bson:"supplementaries"`
+		References      []struct {
+			ID string `bson:"id"`
+		} `bson:"references"`
+		Version int64 `bson:"version"`
+	}
+
+	if err = m.collections.Offers.FindOne(ctx, bson.D{{"_id", "a-new"}}).Decode(&primary); err != nil {
+		t.Fatalf("find error [%s]", err)
+	}
+
+	if !reflect.DeepEqual(primary.Supplementaries, []string{"s-synced"}) || len(primary.References) != 1 ||
+		primary.References[0].ID != "s-synced" || primary.Version < 2 {
+		t.Errorf("primary = %+v, want its supplementary relinked to s-synced on a new version", primary)
+	}
+
+	var bundle struct {
+		Components []struct {
+			ID string `bson:"id"`
+		} `bson:"components"`
+	}
+
+	if err = m.collections.Offers.FindOne(ctx, bson.D{{"_id", "b"}}).Decode(&bundle); err != nil {
+		t.Fatalf("find error [%s]", err)
+	}
+
+	if len(bundle.Components) != 1 || bundle.Components[0].ID != "a-new" {
+		t.Errorf("bundle components = %+v, want the kept a-new", bundle.Components)
+	}
+
+	if err = m.EnsureIndexes(ctx); err != nil {
+		t.Errorf("ensure indexes error [%s] after merging", err)
+	}
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewExchangeRateRepository(client *mongo.Client, database string, table string) *exchangeRateRepository {
+	return &exchangeRateRepository{
+		collection: client.Database(database).Collection(table),
+	}
+}
+
+func (r *exchangeRateRepository) All(ctx context.Context) ([]model.ExchangeRate, error) {
+	cursor, err := r.collection.Find(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	rates := make([]model.ExchangeRate, 0)
+
+	for cursor.Next(ctx) {
+		var rate model.ExchangeRate
+
+		err = cursor.Decode(&rate)
+		if err != nil {
+			return nil, err
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+func (r *exchangeRateRepository) Get(ctx context.Context, currency string) (*model.ExchangeRate, error) {
+	var rate model.ExchangeRate
+
+	err := r.collection.FindOne(ctx, bson.D{{"_id", currency}}).Decode(&rate)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &rate, nil
+}
+
+func (r *exchangeRateRepository) Upsert(ctx context.Context, rate model.ExchangeRate) (*model.ExchangeRate, error) {
+	rate.UpdatedAt = time.Now().Format("2006-01-02 15:04:00")
+
+	upsert := true
+
+	_, err := r.collection.UpdateOne(ctx, bson.D{{"_id", rate.Currency}},
+		bson.D{{"$set", rate}}, &options.UpdateOptions{
+			Upsert: &upsert,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
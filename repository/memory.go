@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// NewMemoryRepository returns an offer repository kept in process memory, nothing is persisted across restarts
+func NewMemoryRepository() *memoryRepository {
+	return &memoryRepository{
+		ids:       make([]string, 0),
+		documents: make(map[string]bson.D),
+	}
+}
+
+func (r *memoryRepository) All(ctx context.Context) ([]model.Offer, error) {
+	return r.find(func(offer model.Offer) bool {
+		return true
+	})
+}
+
+func (r *memoryRepository) Upsert(ctx context.Context, offer model.Offer) (*model.Offer, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	initializeOffer(&offer)
+
+	stored := r.documents[offer.ID]
+
+	if stored != nil && documentVersion(stored) != offer.Version {
+		return nil, fmt.Errorf("%w [%s]", ErrVersionConflict, offer.ID)
+	}
+
+	// the unique external_id index of the mongo repository
+	if stored == nil && offer.ExternalID != nil && r.byExternalID(*offer.ExternalID) != nil {
+		return nil, fmt.Errorf("%w [%s]", ErrVersionConflict, offer.ID)
+	}
+
+	offer.Version++
+
+	fields, err := offerDocument(offer)
+	if err != nil {
+		return nil, err
+	}
+
+	r.store(setDocument(stored, fields))
+
+	return &offer, nil
+}
+
+func (r *memoryRepository) UpsertByExternalID(ctx context.Context, offer model.Offer) (*model.Offer, error) {
+	if offer.ExternalID == nil {
+		return r.Upsert(ctx, offer)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := r.byExternalID(*offer.ExternalID)
+
+	if stored != nil && documentVersion(stored) != offer.Version {
+		return nil, fmt.Errorf("%w [%s]", ErrVersionConflict, *offer.ExternalID)
+	}
+
+	offer.Version++
+
+	document, err := externalIDDocument(stored, offer)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored == nil && r.documents[documentID(document)] != nil {
+		return nil, fmt.Errorf("%w [%s]", ErrVersionConflict, *offer.ExternalID)
+	}
+
+	r.store(document)
+
+	return decodeOffer(document)
+}
+
+func (r *memoryRepository) CreatePending(ctx context.Context, externalID string) (*model.Offer, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if stored := r.byExternalID(externalID); stored != nil {
+		return decodeOffer(stored)
+	}
+
+	document, err := offerDocument(pendingOffer(externalID))
+	if err != nil {
+		return nil, err
+	}
+
+	r.store(document)
+
+	return decodeOffer(document)
+}
+
+func (r *memoryRepository) Get(ctx context.Context, id string) (*model.Offer, error) {
+	return r.GetByExternalID(ctx, id)
+}
+
+func (r *memoryRepository) GetByExternalID(ctx context.Context, id string) (*model.Offer, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	stored := r.byExternalID(id)
+	if stored == nil {
+		return nil, nil
+	}
+
+	return decodeOffer(stored)
+}
+
+func (r *memoryRepository) Search(ctx context.Context, active *bool, category *model.CategoryType,
+	filters model.SearchFilters,
+) ([]model.Offer, error) {
+	now := time.Now().Unix()
+
+	return r.find(func(offer model.Offer) bool {
+		return searchMatches(offer, now, active, category, filters)
+	})
+}
+
+func (r *memoryRepository) RemoveByExternalID(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := r.byExternalID(id)
+	if stored == nil {
+		return nil
+	}
+
+	storedID := documentID(stored)
+
+	delete(r.documents, storedID)
+
+	for i := range r.ids {
+		if r.ids[i] == storedID {
+			r.ids = append(r.ids[:i], r.ids[i+1:]...)
+
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) GetByIDList(ctx context.Context, ids []string) ([]model.Offer, error) {
+	offers, err := r.find(func(offer model.Offer) bool {
+		return idListMatches(offer, ids)
+	})
+	if err != nil || len(offers) == 0 {
+		return nil, err
+	}
+
+	return offers, nil
+}
+
+func (r *memoryRepository) GetBySupplementary(ctx context.Context, externalID string,
+	relationTypes []model.RelationType,
+) ([]model.Offer, error) {
+	return r.find(func(offer model.Offer) bool {
+		return supplementaryMatches(offer, externalID, relationTypes)
+	})
+}
+
+func (r *memoryRepository) GetByCatalog(ctx context.Context, catalogID string) ([]model.Offer, error) {
+	return r.find(func(offer model.Offer) bool {
+		return contains(offer.Catalogs, catalogID)
+	})
+}
+
+// find returns the matching offers in insertion order as mongo returns them without a sort
+func (r *memoryRepository) find(match func(offer model.Offer) bool) ([]model.Offer, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	offers := make([]model.Offer, 0)
+
+	for _, id := range r.ids {
+		offer, err := decodeOffer(r.documents[id])
+		if err != nil {
+			return nil, err
+		}
+
+		if match(*offer) {
+			offers = append(offers, *offer)
+		}
+	}
+
+	return offers, nil
+}
+
+func (r *memoryRepository) byExternalID(externalID string) bson.D {
+	for _, id := range r.ids {
+		for _, field := range r.documents[id] {
+			if field.Key == "external_id" && field.Value == externalID {
+				return r.documents[id]
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) store(document bson.D) {
+	id := documentID(document)
+
+	if _, ok := r.documents[id]; !ok {
+		r.ids = append(r.ids, id)
+	}
+
+	r.documents[id] = document
+}
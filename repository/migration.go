@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrDuplicatedExternalIDs = errors.New("duplicated external ids, run merge-duplicates to create the unique " +
+		"external_id index")
+	ErrMissingUniqueIndex = errors.New("unique external_id index is missing, run migrate or merge-duplicates to " +
+		"create it")
+)
+
+// uniqueIndexName is the index that rejects a second offer with the same external id, versioned upserts rely on it
+const uniqueIndexName = "external_id_unique"
+
+// namespaceNotFound is the mongo error code of commands run on a collection that does not exist
+const namespaceNotFound = 26
+
+// Migration changes stored documents, Up must be idempotent as an interrupted run is retried from its start
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, collections MigrationCollections) error
+}
+
+type MigrationCollections struct {
+	Offers          *mongo.Collection
+	Supplementaries *mongo.Collection
+	Quotes          *mongo.Collection
+	Cache           *mongo.Collection
+}
+
+func NewMigrator(client *mongo.Client, database string, table string, offerTable string, supplementaryTable string,
+	quoteTable string, cacheTable string,
+) *migrator {
+	db := client.Database(database)
+
+	return &migrator{
+		collection: db.Collection(table),
+		collections: MigrationCollections{
+			Offers:          db.Collection(offerTable),
+			Supplementaries: db.Collection(supplementaryTable),
+			Quotes:          db.Collection(quoteTable),
+			Cache:           db.Collection(cacheTable),
+		},
+		migrations: migrations,
+	}
+}
+
+// EnsureIndexes creates the missing indexes, existing ones with the same definition are left untouched. The unique
+// external id index is created last and on its own so duplicated offers do not prevent creating the others
+func (m *migrator) EnsureIndexes(ctx context.Context) error {
+	offerCollections := []*mongo.Collection{m.collections.Offers, m.collections.Supplementaries}
+
+	for _, collection := range offerCollections {
+		if _, err := collection.Indexes().CreateMany(ctx, offerIndexes()); err != nil {
+			return fmt.Errorf("collection [%s] [%w]", collection.Name(), err)
+		}
+	}
+
+	_, err := m.collections.Quotes.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"expires_at", 1}},
+		Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("collection [%s] [%w]", m.collections.Quotes.Name(), err)
+	}
+
+	_, err = m.collections.Cache.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{"expires_at", 1}},
+			Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0),
+		},
+		{
+			Keys:    bson.D{{"tags", 1}},
+			Options: options.Index().SetName("tags"),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("collection [%s] [%w]", m.collections.Cache.Name(), err)
+	}
+
+	for _, collection := range offerCollections {
+		_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{"external_id", 1}},
+			Options: options.Index().SetName(uniqueIndexName).SetUnique(true).
+				SetPartialFilterExpression(bson.D{{"external_id", bson.D{{"$type", "string"}}}}),
+		})
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("collection [%s] [%w]", collection.Name(), ErrDuplicatedExternalIDs)
+		}
+
+		if err != nil {
+			return fmt.Errorf("collection [%s] [%w]", collection.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// CheckIndexes fails with ErrMissingUniqueIndex while an offer collection lacks the unique external id index,
+// without it concurrent upserts of an external id insert duplicated offers
+func (m *migrator) CheckIndexes(ctx context.Context) error {
+	for _, collection := range []*mongo.Collection{m.collections.Offers, m.collections.Supplementaries} {
+		var indexes []bson.M
+
+		cursor, err := collection.Indexes().List(ctx)
+		if err == nil {
+			err = cursor.All(ctx, &indexes)
+		}
+
+		// a collection that does not exist yet has no index either
+		var commandErr mongo.CommandError
+		if err != nil && (!errors.As(err, &commandErr) || commandErr.Code != namespaceNotFound) {
+			return fmt.Errorf("collection [%s] [%w]", collection.Name(), err)
+		}
+
+		found := false
+
+		for _, index := range indexes {
+			if index["name"] == uniqueIndexName {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("collection [%s] [%w]", collection.Name(), ErrMissingUniqueIndex)
+		}
+	}
+
+	return nil
+}
+
+func offerIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			Keys:    bson.D{{"category", 1}, {"type", 1}},
+			Options: options.Index().SetName("category_type"),
+		},
+		{
+			Keys:    bson.D{{"effective_at", 1}, {"expiration_at", 1}},
+			Options: options.Index().SetName("effective_expiration_at"),
+		},
+		{
+			Keys:    bson.D{{"name", "text"}},
+			Options: options.Index().SetName("name_text"),
+		},
+		{
+			Keys:    bson.D{{"references.external_id", 1}},
+			Options: options.Index().SetName("references_external_id"),
+		},
+		{
+			Keys:    bson.D{{"catalogs", 1}},
+			Options: options.Index().SetName("catalogs"),
+		},
+	}
+}
+
+func (m *migrator) Applied(ctx context.Context) ([]model.MigrationRecord, error) {
+	cursor, err := m.collection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{"_id", 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	records := make([]model.MigrationRecord, 0)
+
+	for cursor.Next(ctx) {
+		var record model.MigrationRecord
+
+		if err = cursor.Decode(&record); err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// Migrate runs in version order the migrations not recorded yet and returns the ones it applied
+func (m *migrator) Migrate(ctx context.Context) ([]model.MigrationRecord, error) {
+	records, err := m.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]bool, len(records))
+
+	for _, record := range records {
+		applied[record.Version] = true
+	}
+
+	pending := make([]Migration, 0, len(m.migrations))
+
+	for _, migration := range m.migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Version < pending[j].Version
+	})
+
+	done := make([]model.MigrationRecord, 0, len(pending))
+
+	for _, migration := range pending {
+		if err = migration.Up(ctx, m.collections); err != nil {
+			return done, fmt.Errorf("migration [%d] [%s] [%w]", migration.Version, migration.Description, err)
+		}
+
+		record := model.MigrationRecord{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now().Format("2006-01-02 15:04:00"),
+		}
+
+		// another instance may have applied it meanwhile, migrations are idempotent so only the record is skipped
+		if _, err = m.collection.InsertOne(ctx, record); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return done, err
+		}
+
+		done = append(done, record)
+	}
+
+	return done, nil
+}
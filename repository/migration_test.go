@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testMigrator returns a migrator on a database of its own, it only runs when OFFERS_TEST_MONGO_URI points to a server
+func testMigrator(t *testing.T) *migrator {
+	uri := os.Getenv("OFFERS_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("OFFERS_TEST_MONGO_URI is not set")
+	}
+
+	ctx := context.Background()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting mongo error [%s]", err)
+	}
+
+	database := fmt.Sprintf("offers_migration_%d", time.Now().UnixNano())
+
+	t.Cleanup(func() {
+		client.Database(database).Drop(ctx)
+		client.Disconnect(ctx)
+	})
+
+	return NewMigrator(client, database, "migrations", "offers", "supplementaries", "quotes", "cache")
+}
+
+func TestCheckIndexesRequiresTheUniqueIndex(t *testing.T) {
+	ctx := context.Background()
+
+	m := testMigrator(t)
+
+	if err := m.CheckIndexes(ctx); !errors.Is(err, ErrMissingUniqueIndex) {
+		t.Fatalf("check error [%v] before creating the indexes, want a missing unique index", err)
+	}
+
+	for _, id := range []string{"1", "2"} {
+		if _, err := m.collections.Offers.InsertOne(ctx, bson.D{{"_id", id}, {"external_id", "a"}}); err != nil {
+			t.Fatalf("insert error [%s]", err)
+		}
+	}
+
+	if err := m.EnsureIndexes(ctx); !errors.Is(err, ErrDuplicatedExternalIDs) {
+		t.Fatalf("ensure indexes error [%v], want duplicated external ids", err)
+	}
+
+	if err := m.CheckIndexes(ctx); !errors.Is(err, ErrMissingUniqueIndex) {
+		t.Fatalf("check error [%v] with duplicated offers, want a missing unique index", err)
+	}
+
+	if _, err := m.collections.Offers.DeleteOne(ctx, bson.D{{"_id", "2"}}); err != nil {
+		t.Fatalf("delete error [%s]", err)
+	}
+
+	if err := m.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("ensure indexes error [%s]", err)
+	}
+
+	if err := m.CheckIndexes(ctx); err != nil {
+		t.Errorf("check error [%s] once the indexes exist", err)
+	}
+}
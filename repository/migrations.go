@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// migrations are never edited once released, changes to stored documents are added as a new version
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "mark supplementary placeholders created before pending references as pending",
+		Up: func(ctx context.Context, collections MigrationCollections) error {
+			_, err := collections.Supplementaries.UpdateMany(ctx, bson.D{
+				{"name", bson.D{{"$exists", false}}},
+				{"pending", bson.D{{"$ne", true}}},
+			}, bson.D{{"$set", bson.D{{"pending", true}}}})
+
+			return err
+		},
+	},
+	{
+		Version:     2,
+		Description: "backfill canonical bytes and bits per second of resource quantities",
+		Up: func(ctx context.Context, collections MigrationCollections) error {
+			for _, collection := range []*mongo.Collection{collections.Offers, collections.Supplementaries} {
+				if err := backfillCanonicalUnits(ctx, collection); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		Version:     3,
+		Description: "backfill the canonical months of yellow pages durations",
+		Up: func(ctx context.Context, collections MigrationCollections) error {
+			for _, collection := range []*mongo.Collection{collections.Offers, collections.Supplementaries} {
+				if err := backfillDurationMonths(ctx, collection); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	},
+	{
+		Version:     4,
+		Description: "backfill the unix times of effective and expiration dates",
+		Up: func(ctx context.Context, collections MigrationCollections) error {
+			for _, collection := range []*mongo.Collection{collections.Offers, collections.Supplementaries} {
+				if err := backfillOfferDates(ctx, collection); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	},
+}
+
+func backfillCanonicalUnits(ctx context.Context, collection *mongo.Collection) error {
+	cursor, err := collection.Find(ctx, bson.D{{"data_center_resource_attributes", bson.D{{"$exists", true}}}})
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var offer model.Offer
+
+		if err = cursor.Decode(&offer); err != nil {
+			return err
+		}
+
+		set := canonicalUnitFields(*offer.DataCenterResourceAttributtes)
+		if len(set) == 0 {
+			continue
+		}
+
+		if _, err = collection.UpdateOne(ctx, bson.D{{"_id", offer.ID}}, bson.D{{"$set", set}}); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// canonicalUnitFields returns the base values missing from the attributes, quantities in unknown units are skipped
+func canonicalUnitFields(attributes model.DataCenterResourceAttributtes) bson.D {
+	set := bson.D{}
+
+	addStorage := func(field string, amount float64, unit string, bytes int64) {
+		if b, ok := model.StorageBytes(amount, unit); ok && bytes == 0 && b > 0 {
+			set = append(set, bson.E{"data_center_resource_attributes." + field + ".bytes", b})
+		}
+	}
+
+	addBandwidth := func(field string, amount float64, unit string, bitsPerSecond int64) {
+		if b, ok := model.BandwidthBitsPerSecond(amount, unit); ok && bitsPerSecond == 0 && b > 0 {
+			set = append(set, bson.E{"data_center_resource_attributes." + field + ".bits_per_second", b})
+		}
+	}
+
+	if attributes.RAM != nil {
+		addStorage("ram", attributes.RAM.Amount, attributes.RAM.Unit, attributes.RAM.Bytes)
+	}
+
+	if attributes.HDD != nil {
+		addStorage("hdd", attributes.HDD.Amount, attributes.HDD.Unit, attributes.HDD.Bytes)
+	}
+
+	if attributes.Database != nil {
+		addStorage("database", attributes.Database.Amount, attributes.Database.Unit, attributes.Database.Bytes)
+	}
+
+	if attributes.Bandwidth != nil {
+		addBandwidth("bandwidth", attributes.Bandwidth.Amount, attributes.Bandwidth.Unit,
+			attributes.Bandwidth.BitsPerSecond)
+	}
+
+	if attributes.VPN != nil {
+		addBandwidth("vpn", attributes.VPN.Speed, attributes.VPN.Unit, attributes.VPN.BitsPerSecond)
+	}
+
+	return set
+}
+
+func backfillDurationMonths(ctx context.Context, collection *mongo.Collection) error {
+	cursor, err := collection.Find(ctx, bson.D{
+		{"yellow_pages_attributes.duration", bson.D{{"$exists", true}}},
+		{"yellow_pages_attributes.months", bson.D{{"$exists", false}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var offer model.Offer
+
+		if err = cursor.Decode(&offer); err != nil {
+			return err
+		}
+
+		attributes := offer.YellowPagesAttributes
+
+		// durations in unknown units were reported when mapping and keep no canonical value
+		months, ok := model.DurationMonths(float64(*attributes.Duration), attributes.DurationUnit)
+		if !ok || months == 0 {
+			continue
+		}
+
+		if _, err = collection.UpdateOne(ctx, bson.D{{"_id", offer.ID}},
+			bson.D{{"$set", bson.D{{"yellow_pages_attributes.months", months}}}}); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+func backfillOfferDates(ctx context.Context, collection *mongo.Collection) error {
+	cursor, err := collection.Find(ctx, bson.D{{"$or", []bson.D{
+		{{"effective_date", bson.D{{"$exists", true}}}, {"effective_at", bson.D{{"$exists", false}}}},
+		{{"expiration_date", bson.D{{"$exists", true}}}, {"expiration_at", bson.D{{"$exists", false}}}},
+	}}})
+	if err != nil {
+		return err
+	}
+
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var offer model.Offer
+
+		if err = cursor.Decode(&offer); err != nil {
+			return err
+		}
+
+		// dates that can not be read were reported when mapping and leave the offer unbounded
+		set := bson.D{}
+
+		if date, ok := model.ParseOfferDate(offer.EffectiveDate); ok && offer.EffectiveAt == 0 {
+			set = append(set, bson.E{"effective_at", date})
+		}
+
+		if date, ok := model.ParseOfferDate(offer.ExpirationDate); ok && offer.ExpirationAt == 0 {
+			set = append(set, bson.E{"expiration_at", date})
+		}
+
+		if len(set) == 0 {
+			continue
+		}
+
+		if _, err = collection.UpdateOne(ctx, bson.D{{"_id", offer.ID}}, bson.D{{"$set", set}}); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
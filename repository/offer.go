@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +13,8 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+var ErrVersionConflict = errors.New("offer was modified since it was read")
+
 func NewRepository(client *mongo.Client, database string, table string) *repository {
 	return &repository{
 		collection: client.Database(database).Collection(table),
@@ -42,15 +45,31 @@ func (r *repository) All(ctx context.Context) ([]model.Offer, error) {
 	return offers, nil
 }
 
+// Upsert writes the offer only when the stored document is still on the version of the offer, otherwise
+// ErrVersionConflict is returned
 func (r *repository) Upsert(ctx context.Context, offer model.Offer) (*model.Offer, error) {
-	r.initializeOffer(&offer)
+	initializeOffer(&offer)
+
+	filter := versionFilter(bson.D{{"_id", offer.ID}}, offer.Version)
+	offer.Version++
+
+	fields, err := offerDocument(offer)
+	if err != nil {
+		return nil, err
+	}
 
 	upsert := true
 
-	_, err := r.collection.UpdateOne(ctx, bson.D{{"_id", offer.ID}},
-		bson.D{{"$set", offer}}, &options.UpdateOptions{
+	_, err = r.collection.UpdateOne(ctx, filter,
+		withUnset(bson.D{{"$set", fields}}, fields), &options.UpdateOptions{
 			Upsert: &upsert,
 		})
+
+	// the document exists on another version so the upsert tried to insert a second one with its id
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("%w [%s]", ErrVersionConflict, offer.ID)
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -58,15 +77,90 @@ func (r *repository) Upsert(ctx context.Context, offer model.Offer) (*model.Offe
 	return &offer, nil
 }
 
-func (r *repository) initializeOffer(offer *model.Offer) {
+// UpsertByExternalID writes the offer on the document with its external id in a single atomic operation so concurrent
+// syncs of the same offer can not create duplicates, the id and creation date of an existing document are kept. As
+// Upsert it fails with ErrVersionConflict when the stored document is not on the version of the offer
+func (r *repository) UpsertByExternalID(ctx context.Context, offer model.Offer) (*model.Offer, error) {
+	if offer.ExternalID == nil {
+		return r.Upsert(ctx, offer)
+	}
+
+	filter := versionFilter(bson.D{{"external_id", *offer.ExternalID}}, offer.Version)
+	offer.Version++
+
+	set, setOnInsert, err := externalIDUpdate(offer)
+	if err != nil {
+		return nil, err
+	}
+
+	update := withUnset(bson.D{
+		{"$set", set},
+		{"$setOnInsert", setOnInsert},
+	}, set)
+
+	nOffer, err := r.findOneAndUpsert(ctx, filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("%w [%s]", ErrVersionConflict, *offer.ExternalID)
+	}
+
+	return nOffer, err
+}
+
+// CreatePending stores a pending placeholder for the external id unless a document already has it, the stored
+// document is returned either way
+func (r *repository) CreatePending(ctx context.Context, externalID string) (*model.Offer, error) {
 	now := time.Now().Format("2006-01-02 15:04:00")
 
-	if offer.ID == "" {
-		offer.ID = uuid.NewString()
-		offer.CreatedAt = now
+	update := bson.D{
+		{"$setOnInsert", bson.D{
+			{"_id", uuid.NewString()},
+			{"external_id", externalID},
+			{"pending", true},
+			{"bundle", false},
+			{"version", int64(1)},
+			{"created_at", now},
+			{"updated_at", now},
+		}},
 	}
 
-	offer.UpdatedAt = now
+	return r.findOneAndUpsert(ctx, bson.D{{"external_id", externalID}}, update)
+}
+
+// withUnset adds the $unset of the offer fields left out of the set ones to the update, mongo rejects an empty $unset
+func withUnset(update bson.D, set bson.D) bson.D {
+	if unset := unsetFields(set); len(unset) > 0 {
+		update = append(update, bson.E{"$unset", unset})
+	}
+
+	return update
+}
+
+// versionFilter matches the documents on the version, documents stored before versioning are on version 0
+func versionFilter(filter bson.D, version int64) bson.D {
+	if version == 0 {
+		return append(filter, bson.E{"version", bson.D{{"$in", bson.A{nil, int64(0)}}}})
+	}
+
+	return append(filter, bson.E{"version", version})
+}
+
+func (r *repository) findOneAndUpsert(ctx context.Context, filter bson.D, update bson.D) (*model.Offer, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var offer model.Offer
+
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&offer)
+
+	// when two upserts insert at once the unique external_id index rejects one, retrying updates the inserted document
+	if mongo.IsDuplicateKeyError(err) {
+		err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&offer)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &offer, nil
 }
 
 func (r *repository) Get(ctx context.Context, id string) (*model.Offer, error) {
@@ -103,22 +197,33 @@ func (r *repository) GetByExternalID(ctx context.Context, id string) (*model.Off
 	return &offer, nil
 }
 
-func (r *repository) Search(ctx context.Context, active *bool, category *model.CategoryType) ([]model.Offer, error) {
+func (r *repository) Search(ctx context.Context, active *bool, category *model.CategoryType,
+	filters model.SearchFilters,
+) ([]model.Offer, error) {
 	now := time.Now().Unix()
 
 	query := bson.D{}
 
+	// an offer without a date has no bound on that side
 	if active != nil {
 		query = bson.D{
-			{"effective_date", bson.D{{"$lte", now}}},
-			{"expiration_date", bson.D{{"$gte", now}}},
+			{"$and", []bson.D{
+				{{"$or", []bson.D{
+					{{"effective_at", bson.D{{"$exists", false}}}},
+					{{"effective_at", bson.D{{"$lte", now}}}},
+				}}},
+				{{"$or", []bson.D{
+					{{"expiration_at", bson.D{{"$exists", false}}}},
+					{{"expiration_at", bson.D{{"$gte", now}}}},
+				}}},
+			}},
 		}
 
 		if !*active {
 			query = bson.D{
 				{"$or", []bson.D{
-					{{"effective_date", bson.D{{"$gt", now}}}},
-					{{"expiration_date", bson.D{{"$lt", now}}}},
+					{{"effective_at", bson.D{{"$gt", now}}}},
+					{{"expiration_at", bson.D{{"$lt", now}}}},
 				}},
 			}
 		}
@@ -128,6 +233,29 @@ func (r *repository) Search(ctx context.Context, active *bool, category *model.C
 		query = append(query, bson.D{{"category", *category}}...)
 	}
 
+	query = append(query, rangeFilter("data_center_resource_attributes.ram.bytes", filters.RAMMin, filters.RAMMax)...)
+	query = append(query, rangeFilter("data_center_resource_attributes.hdd.bytes", filters.HDDMin, filters.HDDMax)...)
+	query = append(query, rangeFilter("data_center_resource_attributes.database.bytes", filters.DatabaseMin,
+		filters.DatabaseMax)...)
+	query = append(query, rangeFilter("data_center_resource_attributes.bandwidth.bits_per_second", filters.BandwidthMin,
+		filters.BandwidthMax)...)
+
+	if filters.ListingTier != "" {
+		query = append(query, bson.E{"yellow_pages_attributes.listing_tier", filters.ListingTier})
+	}
+
+	if filters.AdSize != "" {
+		query = append(query, bson.E{"yellow_pages_attributes.ad_size", filters.AdSize})
+	}
+
+	if filters.Region != "" {
+		query = append(query, bson.E{"yellow_pages_attributes.regions", filters.Region})
+	}
+
+	if filters.DurationMin != nil {
+		query = append(query, bson.E{"yellow_pages_attributes.months", bson.D{{"$gte", *filters.DurationMin}}})
+	}
+
 	cursor, err := r.collection.Find(ctx, query)
 	if err != nil {
 		return nil, err
@@ -161,7 +289,15 @@ func (r *repository) RemoveByExternalID(ctx context.Context, id string) error {
 }
 
 func (r *repository) GetByIDList(ctx context.Context, ids []string) ([]model.Offer, error) {
-	filter := bson.D{{"external_id", bson.D{{"$in", ids}}}, {"name", bson.D{{"$exists", true}}}}
+	// ids may be either offer ids or external ids, placeholders stored before the pending flag existed have no name
+	filter := bson.D{
+		{"$or", []bson.D{
+			{{"_id", bson.D{{"$in", ids}}}},
+			{{"external_id", bson.D{{"$in", ids}}}},
+		}},
+		{"pending", bson.D{{"$ne", true}}},
+		{"name", bson.D{{"$exists", true}}},
+	}
 
 	var offers []model.Offer
 
@@ -185,3 +321,78 @@ func (r *repository) GetByIDList(ctx context.Context, ids []string) ([]model.Off
 
 	return offers, nil
 }
+
+func (r *repository) GetBySupplementary(ctx context.Context, externalID string, relationTypes []model.RelationType,
+) ([]model.Offer, error) {
+	reference := bson.D{{"external_id", externalID}}
+
+	if len(relationTypes) > 0 {
+		reference = append(reference, bson.E{"type", bson.D{{"$in", relationTypes}}})
+	}
+
+	filter := bson.D{{"references", bson.D{{"$elemMatch", reference}}}}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	offers := make([]model.Offer, 0)
+
+	for cursor.Next(ctx) {
+		var offer model.Offer
+
+		err = cursor.Decode(&offer)
+		if err != nil {
+			return nil, err
+		}
+
+		offers = append(offers, offer)
+	}
+
+	return offers, nil
+}
+
+func (r *repository) GetByCatalog(ctx context.Context, catalogID string) ([]model.Offer, error) {
+	cursor, err := r.collection.Find(ctx, bson.D{{"catalogs", catalogID}})
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	offers := make([]model.Offer, 0)
+
+	for cursor.Next(ctx) {
+		var offer model.Offer
+
+		err = cursor.Decode(&offer)
+		if err != nil {
+			return nil, err
+		}
+
+		offers = append(offers, offer)
+	}
+
+	return offers, nil
+}
+
+func rangeFilter(field string, min *int64, max *int64) bson.D {
+	if min == nil && max == nil {
+		return bson.D{}
+	}
+
+	bounds := bson.D{}
+
+	if min != nil {
+		bounds = append(bounds, bson.E{"$gte", *min})
+	}
+
+	if max != nil {
+		bounds = append(bounds, bson.E{"$lte", *max})
+	}
+
+	return bson.D{{field, bounds}}
+}
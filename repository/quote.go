@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func NewQuoteRepository(client *mongo.Client, database string, table string) *quoteRepository {
+	return &quoteRepository{
+		collection: client.Database(database).Collection(table),
+	}
+}
+
+func (r *quoteRepository) Get(ctx context.Context, id string) (*model.Quote, error) {
+	var quote model.Quote
+
+	err := r.collection.FindOne(ctx, bson.D{{"_id", id}}).Decode(&quote)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &quote, nil
+}
+
+func (r *quoteRepository) Insert(ctx context.Context, quote model.Quote) (*model.Quote, error) {
+	quote.ID = uuid.NewString()
+
+	if _, err := r.collection.InsertOne(ctx, quote); err != nil {
+		return nil, err
+	}
+
+	return &quote, nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewServiceTypeRepository(client *mongo.Client, database string, table string) *serviceTypeRepository {
+	return &serviceTypeRepository{
+		collection: client.Database(database).Collection(table),
+	}
+}
+
+func (r *serviceTypeRepository) All(ctx context.Context) ([]model.ServiceType, error) {
+	cursor, err := r.collection.Find(ctx, bson.D{})
+	if err != nil {
+		return nil, err
+	}
+
+	defer cursor.Close(ctx)
+
+	serviceTypes := make([]model.ServiceType, 0)
+
+	for cursor.Next(ctx) {
+		var serviceType model.ServiceType
+
+		err = cursor.Decode(&serviceType)
+		if err != nil {
+			return nil, err
+		}
+
+		serviceTypes = append(serviceTypes, serviceType)
+	}
+
+	return serviceTypes, nil
+}
+
+func (r *serviceTypeRepository) Get(ctx context.Context, code string) (*model.ServiceType, error) {
+	var serviceType model.ServiceType
+
+	err := r.collection.FindOne(ctx, bson.D{{"_id", code}}).Decode(&serviceType)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &serviceType, nil
+}
+
+func (r *serviceTypeRepository) Upsert(ctx context.Context, serviceType model.ServiceType) (*model.ServiceType, error) {
+	now := time.Now().Format("2006-01-02 15:04:00")
+
+	serviceType.UpdatedAt = now
+
+	if serviceType.CreatedAt == "" {
+		serviceType.CreatedAt = now
+	}
+
+	upsert := true
+
+	_, err := r.collection.UpdateOne(ctx, bson.D{{"_id", serviceType.Code}},
+		bson.D{{"$set", serviceType}}, &options.UpdateOptions{
+			Upsert: &upsert,
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return &serviceType, nil
+}
+
+func (r *serviceTypeRepository) Remove(ctx context.Context, code string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.D{{"_id", code}})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// NewSQLRepository returns an offer repository on a table of a SQLite or PostgreSQL compatible database, the offers
+// are stored as extended json documents next to the columns they are looked up by. The driver must be registered
+// by the binary, postgres placeholders are used for the pgx and postgres drivers
+func NewSQLRepository(db *sql.DB, driver string, table string) *sqlRepository {
+	return &sqlRepository{
+		db:       db,
+		table:    table,
+		postgres: driver == "postgres" || driver == "pgx",
+	}
+}
+
+// CreateTable creates the offer table when it does not exist
+func (r *sqlRepository) CreateTable(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		id VARCHAR(64) PRIMARY KEY,
+		external_id VARCHAR(255) UNIQUE,
+		category VARCHAR(64),
+		version BIGINT NOT NULL,
+		document TEXT NOT NULL
+	)`, r.table))
+
+	return err
+}
+
+func (r *sqlRepository) All(ctx context.Context) ([]model.Offer, error) {
+	return r.find(ctx, "", nil, func(offer model.Offer) bool {
+		return true
+	})
+}
+
+func (r *sqlRepository) Upsert(ctx context.Context, offer model.Offer) (*model.Offer, error) {
+	initializeOffer(&offer)
+
+	stored, err := r.document(ctx, "id", offer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored != nil && documentVersion(stored) != offer.Version {
+		return nil, fmt.Errorf("%w [%s]", ErrVersionConflict, offer.ID)
+	}
+
+	offer.Version++
+
+	fields, err := offerDocument(offer)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.write(ctx, stored, setDocument(stored, fields)); err != nil {
+		return nil, err
+	}
+
+	return &offer, nil
+}
+
+func (r *sqlRepository) UpsertByExternalID(ctx context.Context, offer model.Offer) (*model.Offer, error) {
+	if offer.ExternalID == nil {
+		return r.Upsert(ctx, offer)
+	}
+
+	stored, err := r.document(ctx, "external_id", *offer.ExternalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored != nil && documentVersion(stored) != offer.Version {
+		return nil, fmt.Errorf("%w [%s]", ErrVersionConflict, *offer.ExternalID)
+	}
+
+	offer.Version++
+
+	document, err := externalIDDocument(stored, offer)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.write(ctx, stored, document); err != nil {
+		return nil, err
+	}
+
+	return decodeOffer(document)
+}
+
+func (r *sqlRepository) CreatePending(ctx context.Context, externalID string) (*model.Offer, error) {
+	stored, err := r.document(ctx, "external_id", externalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored != nil {
+		return decodeOffer(stored)
+	}
+
+	document, err := offerDocument(pendingOffer(externalID))
+	if err != nil {
+		return nil, err
+	}
+
+	// another instance stored the placeholder meanwhile
+	if err = r.write(ctx, nil, document); errors.Is(err, ErrVersionConflict) {
+		return r.GetByExternalID(ctx, externalID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeOffer(document)
+}
+
+func (r *sqlRepository) Get(ctx context.Context, id string) (*model.Offer, error) {
+	return r.GetByExternalID(ctx, id)
+}
+
+func (r *sqlRepository) GetByExternalID(ctx context.Context, id string) (*model.Offer, error) {
+	stored, err := r.document(ctx, "external_id", id)
+	if err != nil || stored == nil {
+		return nil, err
+	}
+
+	return decodeOffer(stored)
+}
+
+// Search narrows the rows by category and applies the rest of the mongo query on the documents
+func (r *sqlRepository) Search(ctx context.Context, active *bool, category *model.CategoryType,
+	filters model.SearchFilters,
+) ([]model.Offer, error) {
+	where := ""
+	args := make([]interface{}, 0, 1)
+
+	if category != nil {
+		where = "category = ?"
+		args = append(args, string(*category))
+	}
+
+	now := time.Now().Unix()
+
+	return r.find(ctx, where, args, func(offer model.Offer) bool {
+		return searchMatches(offer, now, active, category, filters)
+	})
+}
+
+func (r *sqlRepository) RemoveByExternalID(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, r.query("DELETE FROM %s WHERE external_id = ?"), id)
+
+	return err
+}
+
+func (r *sqlRepository) GetByIDList(ctx context.Context, ids []string) ([]model.Offer, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+
+	args := make([]interface{}, 0, 2*len(ids))
+
+	for i := 0; i < 2; i++ {
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+
+	offers, err := r.find(ctx, fmt.Sprintf("id IN (%s) OR external_id IN (%s)", placeholders, placeholders), args,
+		func(offer model.Offer) bool {
+			return idListMatches(offer, ids)
+		})
+	if err != nil || len(offers) == 0 {
+		return nil, err
+	}
+
+	return offers, nil
+}
+
+func (r *sqlRepository) GetBySupplementary(ctx context.Context, externalID string, relationTypes []model.RelationType,
+) ([]model.Offer, error) {
+	return r.find(ctx, "", nil, func(offer model.Offer) bool {
+		return supplementaryMatches(offer, externalID, relationTypes)
+	})
+}
+
+func (r *sqlRepository) GetByCatalog(ctx context.Context, catalogID string) ([]model.Offer, error) {
+	return r.find(ctx, "", nil, func(offer model.Offer) bool {
+		return contains(offer.Catalogs, catalogID)
+	})
+}
+
+func (r *sqlRepository) find(ctx context.Context, where string, args []interface{}, match func(offer model.Offer) bool,
+) ([]model.Offer, error) {
+	query := "SELECT document FROM %s"
+	if where != "" {
+		query += " WHERE " + where
+	}
+
+	rows, err := r.db.QueryContext(ctx, r.query(query), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	offers := make([]model.Offer, 0)
+
+	for rows.Next() {
+		var text string
+
+		if err = rows.Scan(&text); err != nil {
+			return nil, err
+		}
+
+		var offer model.Offer
+
+		if err = bson.UnmarshalExtJSON([]byte(text), true, &offer); err != nil {
+			return nil, err
+		}
+
+		if match(offer) {
+			offers = append(offers, offer)
+		}
+	}
+
+	return offers, rows.Err()
+}
+
+func (r *sqlRepository) document(ctx context.Context, column string, value string) (bson.D, error) {
+	var text string
+
+	err := r.db.QueryRowContext(ctx, r.query("SELECT document FROM %s WHERE "+column+" = ?"), value).Scan(&text)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var document bson.D
+
+	if err = bson.UnmarshalExtJSON([]byte(text), true, &document); err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// write updates the stored document only while it is on the version it was read at, or inserts the document when
+// there is none. An insert rejected because the id or external id is taken is reported as a version conflict
+func (r *sqlRepository) write(ctx context.Context, stored bson.D, document bson.D) error {
+	offer, err := decodeOffer(document)
+	if err != nil {
+		return err
+	}
+
+	text, err := bson.MarshalExtJSON(document, true, false)
+	if err != nil {
+		return err
+	}
+
+	var externalID sql.NullString
+
+	if offer.ExternalID != nil {
+		externalID = sql.NullString{String: *offer.ExternalID, Valid: true}
+	}
+
+	if stored != nil {
+		result, err := r.db.ExecContext(ctx,
+			r.query("UPDATE %s SET external_id = ?, category = ?, version = ?, document = ? WHERE id = ? AND version = ?"),
+			externalID, string(offer.Category), offer.Version, string(text), offer.ID, documentVersion(stored))
+		if err != nil {
+			return err
+		}
+
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if updated == 0 {
+			return fmt.Errorf("%w [%s]", ErrVersionConflict, offer.ID)
+		}
+
+		return nil
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		r.query("INSERT INTO %s (id, external_id, category, version, document) VALUES (?, ?, ?, ?, ?)"),
+		offer.ID, externalID, string(offer.Category), offer.Version, string(text))
+	if err == nil {
+		return nil
+	}
+
+	// drivers report unique violations with their own error types, a row holding the keys tells it was one
+	var count int
+
+	countErr := r.db.QueryRowContext(ctx, r.query("SELECT COUNT(*) FROM %s WHERE id = ? OR external_id = ?"),
+		offer.ID, externalID).Scan(&count)
+	if countErr == nil && count > 0 {
+		return fmt.Errorf("%w [%s]", ErrVersionConflict, offer.ID)
+	}
+
+	return err
+}
+
+// query sets the table name and rewrites the ? placeholders for postgres
+func (r *sqlRepository) query(query string) string {
+	query = fmt.Sprintf(query, r.table)
+
+	if !r.postgres {
+		return query
+	}
+
+	var b strings.Builder
+
+	n := 0
+
+	for _, c := range query {
+		if c == '?' {
+			n++
+
+			b.WriteString("$" + strconv.Itoa(n))
+
+			continue
+		}
+
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func NewTaxRuleRepository(client *mongo.Client, database string, table string) *taxRuleRepository {
+	return &taxRuleRepository{
+		collection: client.Database(database).Collection(table),
+	}
+}
+
+func (r *taxRuleRepository) Latest(ctx context.Context) (*model.TaxRuleSet, error) {
+	var ruleSet model.TaxRuleSet
+
+	err := r.collection.FindOne(ctx, bson.D{}, options.FindOne().SetSort(bson.D{{"_id", -1}})).Decode(&ruleSet)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &ruleSet, nil
+}
+
+func (r *taxRuleRepository) Get(ctx context.Context, version int) (*model.TaxRuleSet, error) {
+	var ruleSet model.TaxRuleSet
+
+	err := r.collection.FindOne(ctx, bson.D{{"_id", version}}).Decode(&ruleSet)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &ruleSet, nil
+}
+
+func (r *taxRuleRepository) Insert(ctx context.Context, ruleSet model.TaxRuleSet) (*model.TaxRuleSet, error) {
+	if _, err := r.collection.InsertOne(ctx, ruleSet); err != nil {
+		return nil, err
+	}
+
+	return &ruleSet, nil
+}
@@ -2,21 +2,116 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"sync"
 
 	"github.com/srrmendez/private-api-offers/model"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type OfferRepository interface {
 	All(ctx context.Context) ([]model.Offer, error)
 	Upsert(ctx context.Context, offer model.Offer) (*model.Offer, error)
+	UpsertByExternalID(ctx context.Context, offer model.Offer) (*model.Offer, error)
+	CreatePending(ctx context.Context, externalID string) (*model.Offer, error)
 	Get(ctx context.Context, id string) (*model.Offer, error)
 	GetByExternalID(ctx context.Context, id string) (*model.Offer, error)
-	Search(ctx context.Context, active *bool, category *model.CategoryType) ([]model.Offer, error)
+	Search(ctx context.Context, active *bool, category *model.CategoryType, filters model.SearchFilters) ([]model.Offer, error)
 	RemoveByExternalID(ctx context.Context, id string) error
 	GetByIDList(ctx context.Context, ids []string) ([]model.Offer, error)
+	GetBySupplementary(ctx context.Context, externalID string, relationTypes []model.RelationType) ([]model.Offer, error)
+	GetByCatalog(ctx context.Context, catalogID string) ([]model.Offer, error)
+}
+
+type CatalogRepository interface {
+	All(ctx context.Context) ([]model.Catalog, error)
+	Get(ctx context.Context, id string) (*model.Catalog, error)
+	Upsert(ctx context.Context, catalog model.Catalog) (*model.Catalog, error)
+}
+
+type ServiceTypeRepository interface {
+	All(ctx context.Context) ([]model.ServiceType, error)
+	Get(ctx context.Context, code string) (*model.ServiceType, error)
+	Upsert(ctx context.Context, serviceType model.ServiceType) (*model.ServiceType, error)
+	Remove(ctx context.Context, code string) error
+}
+
+type ExchangeRateRepository interface {
+	All(ctx context.Context) ([]model.ExchangeRate, error)
+	Get(ctx context.Context, currency string) (*model.ExchangeRate, error)
+	Upsert(ctx context.Context, rate model.ExchangeRate) (*model.ExchangeRate, error)
+}
+
+type TaxRuleRepository interface {
+	Latest(ctx context.Context) (*model.TaxRuleSet, error)
+	Get(ctx context.Context, version int) (*model.TaxRuleSet, error)
+	Insert(ctx context.Context, ruleSet model.TaxRuleSet) (*model.TaxRuleSet, error)
+}
+
+type QuoteRepository interface {
+	Get(ctx context.Context, id string) (*model.Quote, error)
+	Insert(ctx context.Context, quote model.Quote) (*model.Quote, error)
+}
+
+type CacheRepository interface {
+	Get(ctx context.Context, key string) (*model.CacheEntry, error)
+	Set(ctx context.Context, entry model.CacheEntry) error
+	RemoveTags(ctx context.Context, tags []string) error
+	Clear(ctx context.Context) error
+	Generation(ctx context.Context) (int64, error)
+}
+
+type Migrator interface {
+	EnsureIndexes(ctx context.Context) error
+	CheckIndexes(ctx context.Context) error
+	Applied(ctx context.Context) ([]model.MigrationRecord, error)
+	Migrate(ctx context.Context) ([]model.MigrationRecord, error)
+	MergeDuplicates(ctx context.Context, dryRun bool) (*model.DuplicateReport, error)
 }
 
 type repository struct {
 	collection *mongo.Collection
 }
+
+type memoryRepository struct {
+	mutex     sync.RWMutex
+	ids       []string
+	documents map[string]bson.D
+}
+
+type sqlRepository struct {
+	db       *sql.DB
+	table    string
+	postgres bool
+}
+
+type catalogRepository struct {
+	collection *mongo.Collection
+}
+
+type serviceTypeRepository struct {
+	collection *mongo.Collection
+}
+
+type exchangeRateRepository struct {
+	collection *mongo.Collection
+}
+
+type taxRuleRepository struct {
+	collection *mongo.Collection
+}
+
+type quoteRepository struct {
+	collection *mongo.Collection
+}
+
+type cacheRepository struct {
+	collection *mongo.Collection
+}
+
+type migrator struct {
+	collection  *mongo.Collection
+	collections MigrationCollections
+	migrations  []Migration
+}
@@ -0,0 +1,7 @@
+//go:build postgres
+// +build postgres
+
+package server
+
+// the postgres driver is only linked into binaries built with the postgres tag
+import _ "github.com/lib/pq"
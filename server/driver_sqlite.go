@@ -0,0 +1,7 @@
+//go:build sqlite3
+// +build sqlite3
+
+package server
+
+// the sqlite3 driver needs cgo, it is only linked into binaries built with the sqlite3 tag
+import _ "github.com/mattn/go-sqlite3"
@@ -1,15 +1,19 @@
 package server
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/srrmendez/private-api-offers/model"
+	"github.com/srrmendez/private-api-offers/service"
 	pkgHttp "github.com/srrmendez/services-interface-tools/pkg/http"
 )
 
@@ -30,7 +34,26 @@ func healthCheck(w http.ResponseWriter, r *http.Request) {
 // @Param x-client-id header string true "client id"
 // @Param active query bool false "offers status"
 // @Param category query string false "offers categories"
+// @Param expand query string false "supplementaries to resolve the supplementary offers inline"
+// @Param currency query string false "ISO currency to convert prices to"
+// @Param tax_version query int false "tax rules version to compute taxes with, defaults to the version in use"
+// @Param ram_min query string false "minimum ram such as 4GB, MB when no unit is given"
+// @Param ram_max query string false "maximum ram"
+// @Param hdd_min query string false "minimum disk such as 100GB, MB when no unit is given"
+// @Param hdd_max query string false "maximum disk"
+// @Param database_min query string false "minimum database space such as 1GB, MB when no unit is given"
+// @Param database_max query string false "maximum database space"
+// @Param bandwidth_min query string false "minimum bandwidth such as 10Mbps, Mbps when no unit is given"
+// @Param bandwidth_max query string false "maximum bandwidth"
+// @Param listing_tier query string false "yellow pages listing tier"
+// @Param ad_size query string false "yellow pages ad size"
+// @Param region query string false "yellow pages region the offer is published in"
+// @Param duration_min query string false "minimum yellow pages publication duration such as 1YEAR, months when no unit is given"
+// @Param If-None-Match header string false "ETag of a previous response"
 // @Success 200 {array} model.Offer
+// @Header 200 {string} ETag "hash of the response"
+// @Success 304 Not Modified
+// @Failure 400 Incorrect filters
 // @Failure 401 Unauthorized Request
 // @Failure 500 Server Error
 // @Router /v1/ [get]
@@ -52,7 +75,13 @@ func searchOffers(w http.ResponseWriter, r *http.Request) {
 	var category *model.CategoryType
 
 	if cat := r.URL.Query().Get("category"); cat != "" {
-		err := checkRequestCategoryType(cat)
+		categories, err := env.offerService.GetCategories(r.Context(), clientID)
+		if err != nil {
+			pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+			return
+		}
+
+		err = checkRequestCategoryType(cat, categories)
 		if err != nil {
 			pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
 			return
@@ -63,12 +92,34 @@ func searchOffers(w http.ResponseWriter, r *http.Request) {
 		category = &st
 	}
 
-	offers, err := env.offerService.Search(r.Context(), clientID, active, category)
+	options, err := readOptions(r)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	filters, err := searchFilters(r)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	offers, err := env.offerService.Search(r.Context(), clientID, active, category, *filters, *options)
 	if err != nil {
+		if errors.Is(err, service.ErrUnknownCurrency) || errors.Is(err, service.ErrUnknownTaxVersion) {
+			pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+			return
+		}
+
 		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
 		return
 	}
 
+	// removed offers and offers leaving the active dates do not change any update date, only the hash validates a search
+	if notModified(w, r, fmt.Sprintf(`"%s"`, contentHash(offers)), time.Time{}) {
+		return
+	}
+
 	pkgHttp.JsonResponse(w, offers, http.StatusOK)
 }
 
@@ -78,7 +129,15 @@ func searchOffers(w http.ResponseWriter, r *http.Request) {
 // @Produce  json
 // @Param x-client-id header string true "client id"
 // @Param id path string true "id"
+// @Param expand query string false "supplementaries to resolve the supplementary offers inline"
+// @Param currency query string false "ISO currency to convert prices to"
+// @Param tax_version query int false "tax rules version to compute taxes with, defaults to the version in use"
 // @Success 200 {object} model.Offer
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param If-Modified-Since header string false "Last-Modified of a previous response"
+// @Header 200 {string} ETag "version of the offer and hash of the response, send it as If-Match when editing it"
+// @Header 200 {string} Last-Modified "last time the offer or its expanded supplementaries changed"
+// @Success 304 Not Modified
 // @Failure 404 Offer Not Found
 // @Failure 401 Unauthorized Request
 // @Failure 500 Server Error
@@ -93,8 +152,19 @@ func getOffer(w http.ResponseWriter, r *http.Request) {
 
 	id := mux.Vars(r)["id"]
 
-	offer, err := env.offerService.Get(r.Context(), id, clientID)
+	options, err := readOptions(r)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	offer, err := env.offerService.Get(r.Context(), id, clientID, *options)
 	if err != nil {
+		if errors.Is(err, service.ErrUnknownCurrency) || errors.Is(err, service.ErrUnknownTaxVersion) {
+			pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+			return
+		}
+
 		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
 		return
 	}
@@ -104,15 +174,310 @@ func getOffer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if notModified(w, r, offerETag(*offer), offerLastModified(*offer, time.Now())) {
+		return
+	}
+
+	pkgHttp.JsonResponse(w, offer, http.StatusOK)
+}
+
+// Save Offer godoc
+// @Tags Save Offer
+// @Summary Edit a primary offer, If-Match must hold the ETag it was read with
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Param If-Match header string true "ETag of the edited offer"
+// @Param id path string true "offer id"
+// @Param req body model.OfferEditRequest true "edited fields, prices can only be sent unchanged"
+// @Success 200 {object} model.Offer
+// @Header 200 {string} ETag "new version of the offer"
+// @Failure 400 Incorrect body format, If-Match, edited prices or unknown units
+// @Failure 401 Unauthorized Request
+// @Failure 404 Offer Not Found
+// @Failure 412 Offer modified since it was read
+// @Failure 428 Missing If-Match
+// @Failure 500 Server Error
+// @Router /v1/admin/offers/{id} [put]
+func saveOffer(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+
+	if ifMatch == "" {
+		pkgHttp.ErrorResponse(w, errors.New("If-Match header is required"), http.StatusPreconditionRequired)
+		return
+	}
+
+	// the ETag starts with the version of the offer
+	tag := strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+
+	version, err := strconv.ParseInt(strings.SplitN(tag, "-", 2)[0], 10, 64)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, fmt.Errorf("invalid If-Match [%s]", ifMatch), http.StatusBadRequest)
+		return
+	}
+
+	var request model.OfferEditRequest
+
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	request.ID = mux.Vars(r)["id"]
+	request.Version = version
+
+	offer, err := env.offerService.SaveOffer(r.Context(), clientID, request)
+	if err != nil {
+		if errors.Is(err, service.ErrVersionConflict) {
+			pkgHttp.ErrorResponse(w, err, http.StatusPreconditionFailed)
+			return
+		}
+
+		if errors.Is(err, service.ErrPriceEdit) || errors.Is(err, service.ErrUnknownUnit) ||
+			errors.Is(err, service.ErrInvalidDate) {
+			pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+			return
+		}
+
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if offer == nil {
+		pkgHttp.ErrorResponse(w, errors.New("offer not found"), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("ETag", offerETag(*offer))
+
 	pkgHttp.JsonResponse(w, offer, http.StatusOK)
 }
 
+// offerETag is the version of the offer followed by the hash of its representation, which also changes with the
+// converted prices and taxes
+func offerETag(offer model.Offer) string {
+	return fmt.Sprintf(`"%d-%s"`, offer.Version, contentHash(offer))
+}
+
+func contentHash(v interface{}) string {
+	data, _ := json.Marshal(v)
+
+	sum := sha1.Sum(data)
+
+	return hex.EncodeToString(sum[:10])
+}
+
+// offerLastModified returns the latest update date of the offer and its expanded supplementaries. Update dates are
+// stored to the minute, a change later in that minute keeps the date so the end of the minute is used once it passed
+// and the current time before, zero when a date can not be parsed
+func offerLastModified(offer model.Offer, now time.Time) time.Time {
+	var lastModified time.Time
+
+	for _, o := range append([]model.Offer{offer}, offer.SupplementaryOffers...) {
+		updatedAt, err := time.ParseInLocation("2006-01-02 15:04:00", o.UpdatedAt, time.Local)
+		if err != nil {
+			return time.Time{}
+		}
+
+		if updatedAt.After(lastModified) {
+			lastModified = updatedAt
+		}
+	}
+
+	if lastModified = lastModified.Add(time.Minute); lastModified.After(now) {
+		return now
+	}
+
+	return lastModified
+}
+
+// notModified sets the validators of the response and answers 304 when the ones of the request still match,
+// If-None-Match takes precedence over If-Modified-Since. A zero lastModified sends no Last-Modified and ignores
+// If-Modified-Since
+func notModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	lastModified = lastModified.UTC().Truncate(time.Second)
+
+	w.Header().Set("ETag", etag)
+
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if !etagMatches(ifNoneMatch, etag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || lastModified.IsZero() || lastModified.After(since) {
+			return false
+		}
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+
+	return true
+}
+
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Get Catalogs godoc
+// @Tags Get Catalogs
+// @Summary Get the catalogs offers are organized in by the commercial system
+// @Accept  json
+// @Produce  json
+// @Param x-client-id header string true "client id"
+// @Success 200 {array} model.Catalog
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/catalogs [get]
+func getCatalogs(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	catalogs, err := env.offerService.GetCatalogs(r.Context(), clientID)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, catalogs, http.StatusOK)
+}
+
+// Get Catalog Offers godoc
+// @Tags Get Catalog Offers
+// @Accept  json
+// @Produce  json
+// @Param x-client-id header string true "client id"
+// @Param id path string true "catalog id"
+// @Success 200 {array} model.Offer
+// @Failure 404 Catalog Not Found
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/catalogs/{id}/offers [get]
+func getCatalogOffers(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	offers, err := env.offerService.GetCatalogOffers(r.Context(), id, clientID)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if offers == nil {
+		pkgHttp.ErrorResponse(w, errors.New("catalog not found"), http.StatusNotFound)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, offers, http.StatusOK)
+}
+
+// Get Bundle godoc
+// @Tags Get Bundle
+// @Summary Get a bundle offer with the offers it is composed of and its combined pricing in the bundle currency
+// @Accept  json
+// @Produce  json
+// @Param x-client-id header string true "client id"
+// @Param id path string true "id"
+// @Success 200 {object} model.Bundle
+// @Failure 404 Bundle Not Found
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/{id}/components [get]
+func getBundle(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	bundle, err := env.offerService.GetBundle(r.Context(), id, clientID)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if bundle == nil {
+		pkgHttp.ErrorResponse(w, errors.New("bundle not found"), http.StatusNotFound)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, bundle, http.StatusOK)
+}
+
+// Get Offer Diagnostics godoc
+// @Tags Get Offer Diagnostics
+// @Summary Get the mapping diagnostics recorded for an offer on its last sync
+// @Accept  json
+// @Produce  json
+// @Param x-client-id header string true "client id"
+// @Param id path string true "id"
+// @Success 200 {object} model.OfferDiagnostics
+// @Failure 404 Offer Not Found
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/{id}/diagnostics [get]
+func getOfferDiagnostics(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	diagnostics, err := env.offerService.GetDiagnostics(r.Context(), id, clientID)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if diagnostics == nil {
+		pkgHttp.ErrorResponse(w, errors.New("offer not found"), http.StatusNotFound)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, diagnostics, http.StatusOK)
+}
+
 // Get Offer godoc
 // @Tags Get Offer
 // @Accept  json
 // @Produce  json
 // @Param x-client-id header string true "client id"
-// @Param ids query string true "ids"
+// @Param ids query string true "comma separated offer ids or external ids"
 // @Success 200 {array} model.Offer
 // @Failure 404 Offer Not Found
 // @Failure 401 Unauthorized Request
@@ -142,6 +507,51 @@ func getSecondaryOffers(w http.ResponseWriter, r *http.Request) {
 	pkgHttp.JsonResponse(w, offers, http.StatusOK)
 }
 
+// Get Primary Offers godoc
+// @Tags Get Primary Offers
+// @Summary Get the primary offers a supplementary offer can be attached to
+// @Accept  json
+// @Produce  json
+// @Param x-client-id header string true "client id"
+// @Param id path string true "supplementary offer external id"
+// @Param type query string false "comma separated relation types"
+// @Success 200 {array} model.Offer
+// @Failure 400 Incorrect relation type
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/secondary/{id}/primaries [get]
+func getPrimaryOffers(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+
+	var relationTypes []model.RelationType
+
+	if types := r.URL.Query().Get("type"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			if err := checkRequestRelationType(t); err != nil {
+				pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+				return
+			}
+
+			relationTypes = append(relationTypes, model.RelationType(t))
+		}
+	}
+
+	offers, err := env.offerService.GetPrimaryOffers(r.Context(), clientID, id, relationTypes)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, offers, http.StatusOK)
+}
+
 // Create Offers godoc
 // @Tags Create Offers Order
 // @Summary Create Offers from commercial system
@@ -149,9 +559,10 @@ func getSecondaryOffers(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param x-client-id header string true "client id"
 // @Param req body model.BssSyncOfferRequest true "offers to sync"
-// @Success 201
+// @Success 201 {object} model.SyncReport
 // @Failure 400 Incorrect body format
 // @Failure 401 Unauthorized Request
+// @Failure 409 Offer kept being modified while syncing it
 // @Failure 500 Server Error
 // @Router /v1/ [post]
 func createOffers(w http.ResponseWriter, r *http.Request) {
@@ -169,22 +580,696 @@ func createOffers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := env.offerService.Sync(r.Context(), clientID, request); err != nil {
+	report, err := env.offerService.Sync(r.Context(), clientID, request)
+	if err != nil {
+		if errors.Is(err, service.ErrVersionConflict) {
+			pkgHttp.ErrorResponse(w, err, http.StatusConflict)
+			return
+		}
+
 		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
 		return
 	}
 
-	pkgHttp.JsonResponse(w, map[string]string{}, http.StatusCreated)
+	pkgHttp.JsonResponse(w, report, http.StatusCreated)
 }
 
-func checkRequestCategoryType(cat string) error {
-	categories := []model.CategoryType{model.CategoryTypeDataCenter, model.CategoryTypeYellowPages}
+// Reconcile Offers godoc
+// @Tags Reconcile Offers
+// @Summary Sync a full catalog snapshot from commercial system removing offers absent from it
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Param dry_run query bool false "only report what would be removed"
+// @Param req body model.BssSyncOfferRequest true "catalog snapshot"
+// @Success 200 {object} model.ReconcileReport
+// @Failure 400 Incorrect body format
+// @Failure 401 Unauthorized Request
+// @Failure 409 Removal threshold exceeded
+// @Failure 500 Server Error
+// @Router /v1/reconcile [post]
+func reconcileOffers(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
 
-	for _, category := range categories {
-		if cat == string(category) {
-			return nil
-		}
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	dryRun := false
+
+	if dr := r.URL.Query().Get("dry_run"); dr != "" {
+		dryRun, _ = strconv.ParseBool(dr)
+	}
+
+	var request model.BssSyncOfferRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	report, err := env.offerService.Reconcile(r.Context(), clientID, request, dryRun)
+	if err != nil {
+		if errors.Is(err, service.ErrReconcileThreshold) {
+			pkgHttp.ErrorResponse(w, err, http.StatusConflict)
+			return
+		}
+
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, report, http.StatusOK)
+}
+
+// Dangling References godoc
+// @Tags Dangling References
+// @Summary List primary offer references to pending or missing supplementary offers and orphaned supplementaries
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Success 200 {object} model.ReferenceReport
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/references/dangling [get]
+func getDanglingReferences(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	report, err := env.offerService.CheckReferences(r.Context(), clientID)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, report, http.StatusOK)
+}
+
+// Get Service Types godoc
+// @Tags Service Types
+// @Summary Get the registry mapping commercial system service types to offer categories and types
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Success 200 {array} model.ServiceType
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/admin/service-types [get]
+func getServiceTypes(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	serviceTypes, err := env.offerService.GetServiceTypes(r.Context(), clientID)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, serviceTypes, http.StatusOK)
+}
+
+// Save Service Type godoc
+// @Tags Service Types
+// @Summary Create or update a service type, offers pick it up on their next sync
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Param code path string true "commercial system service type code"
+// @Param req body model.ServiceType true "service type"
+// @Success 200 {object} model.ServiceType
+// @Failure 400 Incorrect body format
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/admin/service-types/{code} [put]
+func saveServiceType(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	var request model.ServiceType
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	request.Code = mux.Vars(r)["code"]
+
+	serviceType, err := env.offerService.SaveServiceType(r.Context(), clientID, request)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidServiceType) {
+			pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+			return
+		}
+
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, serviceType, http.StatusOK)
+}
+
+// Remove Service Type godoc
+// @Tags Service Types
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Param code path string true "commercial system service type code"
+// @Success 204
+// @Failure 404 Service Type Not Found
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/admin/service-types/{code} [delete]
+func removeServiceType(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	removed, err := env.offerService.RemoveServiceType(r.Context(), clientID, mux.Vars(r)["code"])
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if !removed {
+		pkgHttp.ErrorResponse(w, errors.New("service type not found"), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Get Exchange Rates godoc
+// @Tags Exchange Rates
+// @Summary Get the units of each currency per unit of the default currency
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Success 200 {array} model.ExchangeRate
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/admin/exchange-rates [get]
+func getExchangeRates(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	rates, err := env.offerService.GetExchangeRates(r.Context(), clientID)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, rates, http.StatusOK)
+}
+
+// Save Exchange Rate godoc
+// @Tags Exchange Rates
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Param currency path string true "ISO currency"
+// @Param req body model.ExchangeRate true "exchange rate"
+// @Success 200 {object} model.ExchangeRate
+// @Failure 400 Incorrect body format
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/admin/exchange-rates/{currency} [put]
+func saveExchangeRate(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	var request model.ExchangeRate
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	request.Currency = mux.Vars(r)["currency"]
+
+	rate, err := env.offerService.SaveExchangeRate(r.Context(), clientID, request)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidExchangeRate) {
+			pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+			return
+		}
+
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, rate, http.StatusOK)
+}
+
+// Compare Offers godoc
+// @Tags Compare Offers
+// @Summary Side by side comparison of the data center resources of the offers with price per resource unit
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Param ids query string true "comma separated offer ids or external ids, between 2 and 10"
+// @Param currency query string false "ISO currency of the prices, defaults to the currency of the first offer"
+// @Success 200 {object} model.Comparison
+// @Failure 400 Incorrect ids or currency
+// @Failure 401 Unauthorized Request
+// @Failure 404 Offers Not Found
+// @Failure 500 Server Error
+// @Router /v1/compare [get]
+func compareOffers(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	ids := strings.Split(r.URL.Query().Get("ids"), ",")
+	if len(ids) < 2 || len(ids) > 10 {
+		pkgHttp.ErrorResponse(w, errors.New("between 2 and 10 ids must be compared"), http.StatusBadRequest)
+		return
+	}
+
+	comparison, err := env.offerService.Compare(r.Context(), clientID, ids, r.URL.Query().Get("currency"))
+	if err != nil {
+		if errors.Is(err, service.ErrOffersNotFound) {
+			pkgHttp.ErrorResponse(w, err, http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, service.ErrUnknownCurrency) {
+			pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+			return
+		}
+
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, comparison, http.StatusOK)
+}
+
+// Recommend Offers godoc
+// @Tags Recommend Offers
+// @Summary Cheapest active data center offers providing the required resources ranked by fare and over provisioning
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Param req body model.RecommendRequest true "required resources"
+// @Success 200 {array} model.Recommendation
+// @Failure 400 Incorrect body format or requirements
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/recommend [post]
+func recommendOffers(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	var request model.RecommendRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	recommendations, err := env.offerService.Recommend(r.Context(), clientID, request)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRecommendation) || errors.Is(err, service.ErrUnknownCurrency) {
+			pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+			return
+		}
+
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, recommendations, http.StatusOK)
+}
+
+// Quote godoc
+// @Tags Quote
+// @Summary Price a primary offer plus the selected supplementaries, the quote can be retrieved by id while valid
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Param req body model.QuoteRequest true "offers to quote"
+// @Success 201 {object} model.Quote
+// @Failure 400 Incorrect body format or selection
+// @Failure 401 Unauthorized Request
+// @Failure 404 Offer Not Found
+// @Failure 500 Server Error
+// @Router /v1/quote [post]
+func createQuote(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	var request model.QuoteRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if request.OfferID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("missing offer_id"), http.StatusBadRequest)
+		return
+	}
+
+	quote, err := env.offerService.Quote(r.Context(), clientID, request)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidQuote) || errors.Is(err, service.ErrUnknownCurrency) {
+			pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+			return
+		}
+
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if quote == nil {
+		pkgHttp.ErrorResponse(w, errors.New("offer not found"), http.StatusNotFound)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, quote, http.StatusCreated)
+}
+
+// Get Quote godoc
+// @Tags Quote
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Param id path string true "quote id"
+// @Success 200 {object} model.Quote
+// @Failure 401 Unauthorized Request
+// @Failure 404 Quote Not Found Or Expired
+// @Failure 500 Server Error
+// @Router /v1/quote/{id} [get]
+func getQuote(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	quote, err := env.offerService.GetQuote(r.Context(), mux.Vars(r)["id"], clientID)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if quote == nil {
+		pkgHttp.ErrorResponse(w, errors.New("quote not found or expired"), http.StatusNotFound)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, quote, http.StatusOK)
+}
+
+// Get Tax Rules godoc
+// @Tags Tax Rules
+// @Summary Get a tax rules version, the version in use when no version is given
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Param version query int false "tax rules version"
+// @Success 200 {object} model.TaxRuleSet
+// @Failure 400 Incorrect version
+// @Failure 401 Unauthorized Request
+// @Failure 404 Tax Rules Not Found
+// @Failure 500 Server Error
+// @Router /v1/admin/tax-rules [get]
+func getTaxRules(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	version, err := checkRequestTaxVersion(r.URL.Query().Get("version"))
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	ruleSet, err := env.offerService.GetTaxRules(r.Context(), clientID, version)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if ruleSet == nil {
+		pkgHttp.ErrorResponse(w, errors.New("tax rules not found"), http.StatusNotFound)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, ruleSet, http.StatusOK)
+}
+
+// Create Tax Rules godoc
+// @Tags Tax Rules
+// @Summary Create a new tax rules version, previous versions are kept to reproduce historical prices
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Param req body []model.TaxRule true "tax rules"
+// @Success 201 {object} model.TaxRuleSet
+// @Failure 400 Incorrect body format
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/admin/tax-rules [post]
+func createTaxRules(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	var request []model.TaxRule
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	ruleSet, err := env.offerService.CreateTaxRules(r.Context(), clientID, request)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTaxRule) {
+			pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+			return
+		}
+
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, ruleSet, http.StatusCreated)
+}
+
+// Validate Offers godoc
+// @Tags Validate Offers
+// @Summary Dry run a commercial system sync payload without writing it
+// @Accept  json
+// @Produce json
+// @Param x-client-id header string true "client id"
+// @Param req body model.BssSyncOfferRequest true "offers to validate"
+// @Success 200 {object} model.SyncValidation
+// @Failure 400 Incorrect body format
+// @Failure 401 Unauthorized Request
+// @Failure 500 Server Error
+// @Router /v1/validate [post]
+func validateOffers(w http.ResponseWriter, r *http.Request) {
+	clientID := r.Header.Get("x-client-id")
+
+	if clientID == "" {
+		pkgHttp.ErrorResponse(w, errors.New("unauthorized"), http.StatusUnauthorized)
+		return
+	}
+
+	var request model.BssSyncOfferRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	validation, err := env.offerService.Validate(r.Context(), clientID, request)
+	if err != nil {
+		pkgHttp.ErrorResponse(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	pkgHttp.JsonResponse(w, validation, http.StatusOK)
+}
+
+func checkRequestCategoryType(cat string, categories []model.CategoryType) error {
+	values := make([]string, 0, len(categories))
+
+	for _, category := range categories {
+		if cat == string(category) {
+			return nil
+		}
+
+		values = append(values, string(category))
+	}
+
+	return fmt.Errorf("incorrect category posible values are %s", strings.Join(values, ", "))
+}
+
+func checkRequestRelationType(t string) error {
+	relationTypes := []model.RelationType{model.MandatoryRelationType, model.OptionalRelationType,
+		model.ExclusiveRelationType, model.BundledRelationType, model.UpgradePathRelationType}
+
+	for _, relationType := range relationTypes {
+		if t == string(relationType) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("incorrect relation type posible values are %s, %s, %s, %s, %s", model.MandatoryRelationType,
+		model.OptionalRelationType, model.ExclusiveRelationType, model.BundledRelationType, model.UpgradePathRelationType)
+}
+
+func readOptions(r *http.Request) (*model.ReadOptions, error) {
+	expandSupplementaries, err := checkRequestExpand(r.URL.Query().Get("expand"))
+	if err != nil {
+		return nil, err
+	}
+
+	taxVersion, err := checkRequestTaxVersion(r.URL.Query().Get("tax_version"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ReadOptions{
+		ExpandSupplementaries: expandSupplementaries,
+		Currency:              r.URL.Query().Get("currency"),
+		TaxVersion:            taxVersion,
+	}, nil
+}
+
+func searchFilters(r *http.Request) (*model.SearchFilters, error) {
+	var filters model.SearchFilters
+
+	storage := map[string]**int64{
+		"ram_min":      &filters.RAMMin,
+		"ram_max":      &filters.RAMMax,
+		"hdd_min":      &filters.HDDMin,
+		"hdd_max":      &filters.HDDMax,
+		"database_min": &filters.DatabaseMin,
+		"database_max": &filters.DatabaseMax,
+	}
+
+	for param, filter := range storage {
+		if q := r.URL.Query().Get(param); q != "" {
+			bytes, err := model.ParseStorage(q)
+			if err != nil {
+				return nil, fmt.Errorf("incorrect %s [%s]", param, err)
+			}
+
+			*filter = &bytes
+		}
+	}
+
+	bandwidth := map[string]**int64{
+		"bandwidth_min": &filters.BandwidthMin,
+		"bandwidth_max": &filters.BandwidthMax,
+	}
+
+	for param, filter := range bandwidth {
+		if q := r.URL.Query().Get(param); q != "" {
+			bitsPerSecond, err := model.ParseBandwidth(q)
+			if err != nil {
+				return nil, fmt.Errorf("incorrect %s [%s]", param, err)
+			}
+
+			*filter = &bitsPerSecond
+		}
+	}
+
+	ranges := map[string][2]*int64{
+		"ram":       {filters.RAMMin, filters.RAMMax},
+		"hdd":       {filters.HDDMin, filters.HDDMax},
+		"database":  {filters.DatabaseMin, filters.DatabaseMax},
+		"bandwidth": {filters.BandwidthMin, filters.BandwidthMax},
+	}
+
+	for name, limits := range ranges {
+		if limits[0] != nil && limits[1] != nil && *limits[0] > *limits[1] {
+			return nil, fmt.Errorf("incorrect %s_min is greater than %s_max", name, name)
+		}
+	}
+
+	filters.ListingTier = strings.ToUpper(r.URL.Query().Get("listing_tier"))
+	filters.AdSize = strings.ToUpper(r.URL.Query().Get("ad_size"))
+	filters.Region = strings.ToUpper(r.URL.Query().Get("region"))
+
+	if q := r.URL.Query().Get("duration_min"); q != "" {
+		months, err := model.ParseDuration(q)
+		if err != nil {
+			return nil, fmt.Errorf("incorrect duration_min [%s]", err)
+		}
+
+		filters.DurationMin = &months
+	}
+
+	return &filters, nil
+}
+
+func checkRequestTaxVersion(version string) (int, error) {
+	if version == "" {
+		return 0, nil
+	}
+
+	v, err := strconv.Atoi(version)
+	if err != nil || v < 1 {
+		return 0, errors.New("incorrect tax_version must be a positive integer")
+	}
+
+	return v, nil
+}
+
+func checkRequestExpand(expand string) (bool, error) {
+	if expand == "" {
+		return false, nil
+	}
+
+	if expand != "supplementaries" {
+		return false, errors.New("incorrect expand posible values are supplementaries")
 	}
 
-	return fmt.Errorf("incorrect category posible values are %s, %s", model.CategoryTypeDataCenter, model.CategoryTypeYellowPages)
+	return true, nil
 }
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+func TestSearchFiltersRanges(t *testing.T) {
+	tests := []struct {
+		query   string
+		wantErr bool
+	}{
+		{query: "ram_min=4GB&ram_max=8GB"},
+		{query: "ram_min=4GB&ram_max=4096MB"},
+		{query: "hdd_min=100GB"},
+		{query: "ram_min=8GB&ram_max=4GB", wantErr: true},
+		{query: "hdd_min=1TB&hdd_max=100GB", wantErr: true},
+		{query: "database_min=2GB&database_max=1GB", wantErr: true},
+		{query: "bandwidth_min=1Gbps&bandwidth_max=100Mbps", wantErr: true},
+		{query: "ram_min=-1GB", wantErr: true},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/v1/?"+test.query, nil)
+
+		_, err := searchFilters(r)
+		if test.wantErr != (err != nil) {
+			t.Errorf("searchFilters(%s) error [%v], want error %t", test.query, err, test.wantErr)
+		}
+	}
+}
+
+func TestOfferLastModified(t *testing.T) {
+	now := time.Date(2026, 10, 19, 10, 30, 20, 0, time.Local)
+
+	tests := []struct {
+		name  string
+		offer model.Offer
+		want  time.Time
+	}{
+		{
+			name:  "updated in an earlier minute",
+			offer: model.Offer{UpdatedAt: "2026-10-19 10:20:00"},
+			want:  time.Date(2026, 10, 19, 10, 21, 0, 0, time.Local),
+		},
+		{
+			name:  "updated in the current minute",
+			offer: model.Offer{UpdatedAt: "2026-10-19 10:30:00"},
+			want:  now,
+		},
+		{
+			name: "supplementary updated later",
+			offer: model.Offer{
+				UpdatedAt:           "2026-10-19 10:20:00",
+				SupplementaryOffers: []model.Offer{{UpdatedAt: "2026-10-19 10:25:00"}},
+			},
+			want: time.Date(2026, 10, 19, 10, 26, 0, 0, time.Local),
+		},
+		{
+			name:  "without update date",
+			offer: model.Offer{},
+		},
+	}
+
+	for _, test := range tests {
+		if got := offerLastModified(test.offer, now); !got.Equal(test.want) {
+			t.Errorf("%s last modified = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNotModifiedWithoutLastModified(t *testing.T) {
+	r := httptest.NewRequest("GET", "/v1/", nil)
+	r.Header.Set("If-Modified-Since", time.Now().UTC().Format(http.TimeFormat))
+
+	w := httptest.NewRecorder()
+
+	if notModified(w, r, `"hash"`, time.Time{}) {
+		t.Error("search answered 304 from If-Modified-Since")
+	}
+
+	if w.Header().Get("Last-Modified") != "" {
+		t.Errorf("Last-Modified = %s, want none", w.Header().Get("Last-Modified"))
+	}
+}
@@ -21,6 +21,76 @@ var Routes = pkgHttp.Routes{
 		Method:     http.MethodGet,
 		ShouldLog:  true,
 	},
+	{
+		Name:       "Dangling References",
+		Pattern:    "/v1/references/dangling",
+		HandleFunc: getDanglingReferences,
+		Method:     http.MethodGet,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Primary Offers",
+		Pattern:    "/v1/secondary/{id}/primaries",
+		HandleFunc: getPrimaryOffers,
+		Method:     http.MethodGet,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Catalogs",
+		Pattern:    "/v1/catalogs",
+		HandleFunc: getCatalogs,
+		Method:     http.MethodGet,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Catalog Offers",
+		Pattern:    "/v1/catalogs/{id}/offers",
+		HandleFunc: getCatalogOffers,
+		Method:     http.MethodGet,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Compare Offers",
+		Pattern:    "/v1/compare",
+		HandleFunc: compareOffers,
+		Method:     http.MethodGet,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Recommend Offers",
+		Pattern:    "/v1/recommend",
+		HandleFunc: recommendOffers,
+		Method:     http.MethodPost,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Quote",
+		Pattern:    "/v1/quote",
+		HandleFunc: createQuote,
+		Method:     http.MethodPost,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Get Quote",
+		Pattern:    "/v1/quote/{id}",
+		HandleFunc: getQuote,
+		Method:     http.MethodGet,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Get Bundle",
+		Pattern:    "/v1/{id}/components",
+		HandleFunc: getBundle,
+		Method:     http.MethodGet,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Get Offer Diagnostics",
+		Pattern:    "/v1/{id}/diagnostics",
+		HandleFunc: getOfferDiagnostics,
+		Method:     http.MethodGet,
+		ShouldLog:  true,
+	},
 	{
 		Name:       "Get Offer",
 		Pattern:    "/v1/{id}",
@@ -42,4 +112,74 @@ var Routes = pkgHttp.Routes{
 		Method:     http.MethodPost,
 		ShouldLog:  true,
 	},
+	{
+		Name:       "Validate Offers",
+		Pattern:    "/v1/validate",
+		HandleFunc: validateOffers,
+		Method:     http.MethodPost,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Reconcile Offers",
+		Pattern:    "/v1/reconcile",
+		HandleFunc: reconcileOffers,
+		Method:     http.MethodPost,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Service Types",
+		Pattern:    "/v1/admin/service-types",
+		HandleFunc: getServiceTypes,
+		Method:     http.MethodGet,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Save Service Type",
+		Pattern:    "/v1/admin/service-types/{code}",
+		HandleFunc: saveServiceType,
+		Method:     http.MethodPut,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Remove Service Type",
+		Pattern:    "/v1/admin/service-types/{code}",
+		HandleFunc: removeServiceType,
+		Method:     http.MethodDelete,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Exchange Rates",
+		Pattern:    "/v1/admin/exchange-rates",
+		HandleFunc: getExchangeRates,
+		Method:     http.MethodGet,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Save Exchange Rate",
+		Pattern:    "/v1/admin/exchange-rates/{currency}",
+		HandleFunc: saveExchangeRate,
+		Method:     http.MethodPut,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Save Offer",
+		Pattern:    "/v1/admin/offers/{id}",
+		HandleFunc: saveOffer,
+		Method:     http.MethodPut,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Tax Rules",
+		Pattern:    "/v1/admin/tax-rules",
+		HandleFunc: getTaxRules,
+		Method:     http.MethodGet,
+		ShouldLog:  true,
+	},
+	{
+		Name:       "Create Tax Rules",
+		Pattern:    "/v1/admin/tax-rules",
+		HandleFunc: createTaxRules,
+		Method:     http.MethodPost,
+		ShouldLog:  true,
+	},
 }
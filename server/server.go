@@ -2,6 +2,8 @@ package server
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -13,6 +15,7 @@ import (
 	"github.com/srrmendez/private-api-offers/conf"
 
 	//"github.com/srrmendez/private-api-offers/docs"
+	"github.com/srrmendez/private-api-offers/model"
 	"github.com/srrmendez/private-api-offers/repository"
 	"github.com/srrmendez/private-api-offers/service"
 	pkgHttp "github.com/srrmendez/services-interface-tools/pkg/http"
@@ -53,25 +56,114 @@ func Init() {
 
 	ctx := context.TODO()
 
-	mongoAddr := fmt.Sprintf("mongodb://%s:%d", conf.GetProps().Database.Host, conf.GetProps().Database.Port)
-
-	mongoClient, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoAddr))
+	mongoClient, err := connectMongo(ctx)
 	if err != nil {
 		panic(err)
 	}
 
 	defer mongoClient.Disconnect(ctx)
 
-	offerRepository := repository.NewRepository(mongoClient, conf.GetProps().Database.Database,
-		conf.GetProps().Database.Table)
+	migrator := newMigrator(mongoClient)
+
+	if conf.GetProps().Migrations.OnStartup {
+		// the migrations are applied even when the unique index can not be created, it is checked below
+		if err = migrator.EnsureIndexes(ctx); err != nil {
+			msg := fmt.Sprintf("ensuring indexes error [%s]", err)
+
+			lg.Error(msg)
+		}
+
+		if _, err = migrator.Migrate(ctx); err != nil {
+			panic(err)
+		}
+	}
+
+	// without the unique index versioned upserts insert duplicated offers, the service does not start until
+	// merge-duplicates creates it
+	if backend := conf.GetProps().Storage.Backend; backend == "" || backend == "mongo" {
+		if err = migrator.CheckIndexes(ctx); err != nil {
+			msg := fmt.Sprintf("checking indexes error [%s]", err)
+
+			lg.Error(msg)
 
-	supplementaryRepository := repository.NewRepository(mongoClient, conf.GetProps().Database.Database,
-		conf.GetProps().Database.SupplementaryTable)
+			panic(err)
+		}
+	}
+
+	offerRepository, supplementaryRepository, err := newOfferRepositories(ctx, mongoClient)
+	if err != nil {
+		panic(err)
+	}
+
+	catalogRepository := repository.NewCatalogRepository(mongoClient, conf.GetProps().Database.Database,
+		conf.GetProps().Database.CatalogTable)
+
+	serviceTypeRepository := repository.NewServiceTypeRepository(mongoClient, conf.GetProps().Database.Database,
+		conf.GetProps().Database.ServiceTypeTable)
+
+	exchangeRateRepository := repository.NewExchangeRateRepository(mongoClient, conf.GetProps().Database.Database,
+		conf.GetProps().Database.ExchangeRateTable)
+
+	taxRuleRepository := repository.NewTaxRuleRepository(mongoClient, conf.GetProps().Database.Database,
+		conf.GetProps().Database.TaxRuleTable)
+
+	quoteRepository := repository.NewQuoteRepository(mongoClient, conf.GetProps().Database.Database,
+		conf.GetProps().Database.QuoteTable)
 
 	trackingClient := tracking.NewRestTracking(resty.New(), conf.GetProps().PrivateApiTracking.Host, lg)
 
+	var offerService service.OfferService
+
+	offerService = service.NewService(offerRepository, supplementaryRepository, catalogRepository, serviceTypeRepository,
+		exchangeRateRepository, taxRuleRepository, quoteRepository, lg, trackingClient, service.Settings{
+			StrictMapping:     conf.GetProps().Mapping.Strict,
+			UsageAttribute:    conf.GetProps().Mapping.UsageAttribute,
+			BillingAttributes: conf.GetProps().Mapping.BillingAttributes,
+			MaxRemovalRatio:   conf.GetProps().Reconcile.MaxRemovalRatio,
+			RemoveOrphans:     conf.GetProps().References.RemoveOrphans,
+			OrphanGracePeriod: time.Duration(conf.GetProps().References.OrphanGracePeriod) * time.Second,
+			RelationTypes:     conf.GetProps().RelationTypes,
+			DefaultCurrency:   conf.GetProps().Currency.Default,
+			Currencies:        conf.GetProps().Currency.Measures,
+			QuoteValidity:     time.Duration(conf.GetProps().Quote.ValidityMinutes) * time.Minute,
+		})
+
+	if cache := conf.GetProps().Cache; cache.Size > 0 {
+		var shared repository.CacheRepository
+
+		if cache.Shared {
+			shared = repository.NewCacheRepository(mongoClient, conf.GetProps().Database.Database,
+				conf.GetProps().Database.CacheTable)
+		}
+
+		offerService = service.NewCachedService(offerService, cache.Size, time.Duration(cache.TTL)*time.Second, shared, lg)
+	}
+
 	env = Env{
-		offerService: service.NewService(offerRepository, supplementaryRepository, lg, conf.GetProps().Categories, trackingClient),
+		offerService: offerService,
+	}
+
+	if err = env.offerService.SeedServiceTypes(ctx, conf.GetProps().Categories); err != nil {
+		panic(err)
+	}
+
+	if err = env.offerService.SeedTaxRules(ctx, conf.GetProps().Taxes.Rules); err != nil {
+		panic(err)
+	}
+
+	if path := conf.GetProps().Currency.ExchangeRatesFile; path != "" {
+		rates, err := conf.LoadExchangeRates(path)
+		if err != nil {
+			panic(err)
+		}
+
+		if err = env.offerService.SeedExchangeRates(ctx, rates); err != nil {
+			panic(err)
+		}
+	}
+
+	if interval := conf.GetProps().References.ResolveInterval; interval > 0 {
+		go resolveReferences(time.Duration(interval) * time.Second)
 	}
 
 	// Creating http logger
@@ -114,3 +206,133 @@ func Init() {
 		panic(err)
 	}
 }
+
+func resolveReferences(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+
+	for range ticker.C {
+		// errors are already logged by the service
+		_, _ = env.offerService.ResolveReferences(context.Background(), "REFERENCE_RESOLVER")
+	}
+}
+
+// Migrate ensures the indexes and runs the pending document migrations, duplicated offers only prevent creating the
+// unique external id index
+func Migrate() error {
+	ctx := context.TODO()
+
+	mongoClient, err := connectMongo(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer mongoClient.Disconnect(ctx)
+
+	migrator := newMigrator(mongoClient)
+
+	indexErr := migrator.EnsureIndexes(ctx)
+	if indexErr != nil && !errors.Is(indexErr, repository.ErrDuplicatedExternalIDs) {
+		return indexErr
+	}
+
+	applied, err := migrator.Migrate(ctx)
+
+	for _, record := range applied {
+		fmt.Printf("applied migration %d: %s\n", record.Version, record.Description)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return indexErr
+}
+
+// MergeDuplicates keeps one offer per external id, removing the duplicates created before the unique index existed,
+// the unique index is created once they are merged
+func MergeDuplicates(dryRun bool) (*model.DuplicateReport, error) {
+	ctx := context.TODO()
+
+	mongoClient, err := connectMongo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	defer mongoClient.Disconnect(ctx)
+
+	migrator := newMigrator(mongoClient)
+
+	report, err := migrator.MergeDuplicates(ctx, dryRun)
+	if err != nil || dryRun {
+		return report, err
+	}
+
+	if err = migrator.EnsureIndexes(ctx); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func connectMongo(ctx context.Context) (*mongo.Client, error) {
+	mongoAddr := fmt.Sprintf("mongodb://%s:%d", conf.GetProps().Database.Host, conf.GetProps().Database.Port)
+
+	return mongo.Connect(ctx, options.Client().ApplyURI(mongoAddr))
+}
+
+// newOfferRepositories returns the offer and supplementary offer repositories of the configured storage backend
+func newOfferRepositories(ctx context.Context, mongoClient *mongo.Client,
+) (repository.OfferRepository, repository.OfferRepository, error) {
+	database := conf.GetProps().Database
+	storage := conf.GetProps().Storage
+
+	switch storage.Backend {
+	case "", "mongo":
+		return repository.NewRepository(mongoClient, database.Database, database.Table),
+			repository.NewRepository(mongoClient, database.Database, database.SupplementaryTable), nil
+	case "memory":
+		return repository.NewMemoryRepository(), repository.NewMemoryRepository(), nil
+	case "sql":
+		if !sqlDriverLinked(storage.Driver) {
+			return nil, nil, fmt.Errorf("sql driver [%s] is not linked, build with the %s tag", storage.Driver,
+				storage.Driver)
+		}
+
+		db, err := sql.Open(storage.Driver, storage.DSN)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		offerRepository := repository.NewSQLRepository(db, storage.Driver, database.Table)
+		supplementaryRepository := repository.NewSQLRepository(db, storage.Driver, database.SupplementaryTable)
+
+		if err = offerRepository.CreateTable(ctx); err != nil {
+			return nil, nil, err
+		}
+
+		if err = supplementaryRepository.CreateTable(ctx); err != nil {
+			return nil, nil, err
+		}
+
+		return offerRepository, supplementaryRepository, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown storage backend [%s]", storage.Backend)
+}
+
+// sqlDriverLinked tells if the driver was linked into the binary by the build tag named after it
+func sqlDriverLinked(driver string) bool {
+	for _, name := range sql.Drivers() {
+		if name == driver {
+			return true
+		}
+	}
+
+	return false
+}
+
+func newMigrator(mongoClient *mongo.Client) repository.Migrator {
+	return repository.NewMigrator(mongoClient, conf.GetProps().Database.Database, conf.GetProps().Database.MigrationTable,
+		conf.GetProps().Database.Table, conf.GetProps().Database.SupplementaryTable,
+		conf.GetProps().Database.QuoteTable, conf.GetProps().Database.CacheTable)
+}
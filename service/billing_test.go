@@ -0,0 +1,204 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/srrmendez/private-api-offers/conf"
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+func TestMapBilling(t *testing.T) {
+	s := &service{settings: Settings{DefaultCurrency: "USD"}}
+
+	tests := []struct {
+		name         string
+		attributes   []model.BssAttribute
+		wantBilling  map[string]model.ResourceBilling
+		wantIncluded bool
+	}{
+		{
+			name: "included resources",
+			attributes: []model.BssAttribute{
+				{Code: "CN_CPU_NUM", Value: "2", Type: "1"},
+				{Code: "CN_RAM_SPACE", Value: "4", Type: "1"},
+			},
+			wantBilling: map[string]model.ResourceBilling{
+				"cpu": {Type: model.IncludedBillingType, IncludedQuantity: 2},
+				"ram": {Type: model.IncludedBillingType, IncludedQuantity: 4},
+			},
+			wantIncluded: true,
+		},
+		{
+			name: "extra resources keep their amount on the resource only",
+			attributes: []model.BssAttribute{
+				{Code: "CN_CPU_NUM", Value: "2", Type: "1"},
+				{Code: "CN_IP_NUM", Value: "3", Type: "0"},
+				{Code: "C_DISK_SPACE", Value: "100", Type: "2"},
+			},
+			wantBilling: map[string]model.ResourceBilling{
+				"cpu":        {Type: model.IncludedBillingType, IncludedQuantity: 2},
+				"public_ips": {Type: model.ExtraBillingType},
+				"hdd":        {Type: model.ExtraBillingType},
+			},
+		},
+		{
+			name: "rate of a vpn offer",
+			attributes: []model.BssAttribute{
+				{Code: "C_DATAC_ACCESS_TYPE", Value: "VPN", Type: "1"},
+				{Code: "C_RATE_NUM", Value: "10", Type: "1"},
+			},
+			wantBilling: map[string]model.ResourceBilling{
+				"vpn_speed": {Type: model.IncludedBillingType, IncludedQuantity: 10},
+			},
+			wantIncluded: true,
+		},
+		{
+			name: "rate of an internet offer",
+			attributes: []model.BssAttribute{
+				{Code: "C_RATE_NUM", Value: "10"},
+			},
+			wantBilling: map[string]model.ResourceBilling{
+				"bandwidth": {Type: model.ExtraBillingType},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			offer, err := s.mapBssOfferToOffer(model.BssOffer{
+				Attributes: &model.BssAttributeList{Attribute: test.attributes},
+			})
+			if err != nil {
+				t.Fatalf("mapping error [%s]", err)
+			}
+
+			attributes := offer.DataCenterResourceAttributtes
+			if attributes == nil {
+				t.Fatal("data center attributes were not mapped")
+			}
+
+			if !reflect.DeepEqual(attributes.Billing, test.wantBilling) {
+				t.Errorf("billing = %+v, want %+v", attributes.Billing, test.wantBilling)
+			}
+
+			if attributes.Included != test.wantIncluded {
+				t.Errorf("included = %t, want %t", attributes.Included, test.wantIncluded)
+			}
+		})
+	}
+}
+
+func TestMapBillingUnconfiguredAttributesAreUnknown(t *testing.T) {
+	s := &service{settings: Settings{DefaultCurrency: "USD"}}
+
+	offer, err := s.mapBssOfferToOffer(model.BssOffer{
+		Attributes: &model.BssAttributeList{Attribute: []model.BssAttribute{
+			{Code: "CN_IP_NUM", Value: "3", Type: "0"},
+			{Code: "CN_IP_NUM_PRICE", Value: "2.50", Type: "0"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("mapping error [%s]", err)
+	}
+
+	if len(offer.Diagnostics) != 1 || offer.Diagnostics[0].Type != model.UnknownAttributeDiagnostic ||
+		offer.Diagnostics[0].Code != "CN_IP_NUM_PRICE" {
+		t.Errorf("diagnostics = %+v, want an unknown CN_IP_NUM_PRICE attribute", offer.Diagnostics)
+	}
+}
+
+func TestMapBillingConfiguredAttributes(t *testing.T) {
+	s := &service{settings: Settings{
+		DefaultCurrency: "USD",
+		BillingAttributes: map[string]conf.BillingAttribute{
+			"public_ips": {MaxQuantity: "CN_IP_NUM_MAX", UnitPrice: "CN_IP_NUM_PRICE"},
+			"ram":        {MaxQuantity: "CN_RAM_SPACE_MAX", UnitPrice: "CN_RAM_SPACE_PRICE"},
+			"vpn_speed":  {UnitPrice: "C_RATE_PRICE"},
+		},
+	}}
+
+	max := 8.0
+	ipPrice := model.Money{Cents: 250, Currency: "USD"}
+	vpnPrice := model.Money{Cents: 100, Currency: "USD"}
+
+	tests := []struct {
+		name            string
+		attributes      []model.BssAttribute
+		wantBilling     map[string]model.ResourceBilling
+		wantDiagnostics []string
+	}{
+		{
+			name: "extra resource",
+			attributes: []model.BssAttribute{
+				{Code: "CN_IP_NUM", Value: "3", Type: "0"},
+				{Code: "CN_IP_NUM_MAX", Value: "8", Type: "0"},
+				{Code: "CN_IP_NUM_PRICE", Value: "2.50", Type: "0"},
+			},
+			wantBilling: map[string]model.ResourceBilling{
+				"public_ips": {Type: model.ExtraBillingType, MaxQuantity: &max, UnitPrice: &ipPrice},
+			},
+		},
+		{
+			name: "resource without its attributes",
+			attributes: []model.BssAttribute{
+				{Code: "CN_IP_NUM", Value: "3", Type: "0"},
+			},
+			wantBilling: map[string]model.ResourceBilling{
+				"public_ips": {Type: model.ExtraBillingType},
+			},
+		},
+		{
+			name: "invalid values",
+			attributes: []model.BssAttribute{
+				{Code: "CN_RAM_SPACE", Value: "4", Type: "1"},
+				{Code: "CN_RAM_SPACE_MAX", Value: "many", Type: "1"},
+				{Code: "CN_RAM_SPACE_PRICE", Value: "free", Type: "1"},
+			},
+			wantBilling: map[string]model.ResourceBilling{
+				"ram": {Type: model.IncludedBillingType, IncludedQuantity: 4},
+			},
+			wantDiagnostics: []string{"CN_RAM_SPACE_MAX", "CN_RAM_SPACE_PRICE"},
+		},
+		{
+			name: "vpn speed",
+			attributes: []model.BssAttribute{
+				{Code: "C_DATAC_ACCESS_TYPE", Value: "VPN", Type: "1"},
+				{Code: "C_RATE_NUM", Value: "10", Type: "2"},
+				{Code: "C_RATE_PRICE", Value: "1", Type: "2"},
+			},
+			wantBilling: map[string]model.ResourceBilling{
+				"vpn_speed": {Type: model.ExtraBillingType, UnitPrice: &vpnPrice},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			offer, err := s.mapBssOfferToOffer(model.BssOffer{
+				Attributes: &model.BssAttributeList{Attribute: test.attributes},
+			})
+			if err != nil {
+				t.Fatalf("mapping error [%s]", err)
+			}
+
+			if billing := offer.DataCenterResourceAttributtes.Billing; !reflect.DeepEqual(billing, test.wantBilling) {
+				t.Errorf("billing = %+v, want %+v", billing, test.wantBilling)
+			}
+
+			codes := make([]string, 0, len(offer.Diagnostics))
+
+			for _, diagnostic := range offer.Diagnostics {
+				if diagnostic.Type != model.InvalidValueDiagnostic {
+					t.Errorf("diagnostic %+v, want only invalid values", diagnostic)
+				}
+
+				codes = append(codes, diagnostic.Code)
+			}
+
+			if len(codes) != len(test.wantDiagnostics) || len(codes) > 0 && !reflect.DeepEqual(codes, test.wantDiagnostics) {
+				t.Errorf("diagnostics = %v, want %v", codes, test.wantDiagnostics)
+			}
+		})
+	}
+}
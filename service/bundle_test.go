@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+func pricedOffer(id string, fare int64, activationFare int64, currency string) model.Offer {
+	return model.Offer{
+		ID:   id,
+		Name: id,
+		Price: &model.OfferPrice{
+			Fare:           model.Money{Cents: fare, Currency: currency},
+			ActivationFare: model.Money{Cents: activationFare, Currency: currency},
+		},
+	}
+}
+
+func TestBundlePricingConvertsComponents(t *testing.T) {
+	ctx := context.Background()
+
+	s := &service{
+		exchangeRateRepository: &memoryExchangeRates{rates: []model.ExchangeRate{{Currency: "EUR", Rate: "0.5"}}},
+		settings:               Settings{DefaultCurrency: "USD"},
+	}
+
+	bundle := pricedOffer("bundle", 2500, 1000, "USD")
+
+	tests := []struct {
+		name       string
+		components []model.Offer
+		want       model.BundlePricing
+		wantErr    error
+	}{
+		{
+			name:       "same currency",
+			components: []model.Offer{pricedOffer("a", 1000, 500, "USD"), pricedOffer("b", 2000, 700, "USD")},
+			want: model.BundlePricing{
+				Fare:                     model.Money{Cents: 2500, Currency: "USD"},
+				ActivationFare:           model.Money{Cents: 1000, Currency: "USD"},
+				ComponentsFare:           model.Money{Cents: 3000, Currency: "USD"},
+				ComponentsActivationFare: model.Money{Cents: 1200, Currency: "USD"},
+				Savings:                  model.Money{Cents: 500, Currency: "USD"},
+				ActivationSavings:        model.Money{Cents: 200, Currency: "USD"},
+			},
+		},
+		{
+			name:       "converted component",
+			components: []model.Offer{pricedOffer("a", 1000, 500, "USD"), pricedOffer("b", 1000, 350, "EUR")},
+			want: model.BundlePricing{
+				Fare:                     model.Money{Cents: 2500, Currency: "USD"},
+				ActivationFare:           model.Money{Cents: 1000, Currency: "USD"},
+				ComponentsFare:           model.Money{Cents: 3000, Currency: "USD"},
+				ComponentsActivationFare: model.Money{Cents: 1200, Currency: "USD"},
+				Savings:                  model.Money{Cents: 500, Currency: "USD"},
+				ActivationSavings:        model.Money{Cents: 200, Currency: "USD"},
+			},
+		},
+		{
+			name:       "currency without rate",
+			components: []model.Offer{pricedOffer("a", 1000, 500, "USD"), pricedOffer("b", 1000, 0, "GBP")},
+			wantErr:    ErrUnknownCurrency,
+		},
+	}
+
+	for _, test := range tests {
+		pricing, err := s.bundlePricing(ctx, bundle, test.components)
+		if !errors.Is(err, test.wantErr) {
+			t.Fatalf("%s error [%v], want [%v]", test.name, err, test.wantErr)
+		}
+
+		if err == nil && *pricing != test.want {
+			t.Errorf("%s pricing = %+v, want %+v", test.name, *pricing, test.want)
+		}
+	}
+}
@@ -0,0 +1,475 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"github.com/srrmendez/private-api-offers/repository"
+	log "github.com/srrmendez/services-interface-tools/pkg/logger"
+)
+
+// searchTag is the tag of every cached search, any offer change may add or remove offers from a search
+const searchTag = "search"
+
+// NewCachedService wraps the service with an in-process cache of size reads kept for ttl, also stored in the shared
+// cache when it is not nil
+func NewCachedService(next OfferService, size int, ttl time.Duration, shared repository.CacheRepository, logger log.Log,
+) *cachedService {
+	return &cachedService{
+		OfferService: next,
+		logger:       logger,
+		local:        newLRUCache(size),
+		shared:       shared,
+		ttl:          ttl,
+	}
+}
+
+func (c *cachedService) Search(ctx context.Context, appID string, active *bool, category *model.CategoryType,
+	filters model.SearchFilters, options model.ReadOptions,
+) ([]model.Offer, error) {
+	key := cacheKey("search", struct {
+		Active   *bool
+		Category *model.CategoryType
+		Filters  model.SearchFilters
+		Options  model.ReadOptions
+	}{active, category, filters, options})
+
+	var offers []model.Offer
+
+	generation, ok := c.read(ctx, appID, key, &offers)
+	if ok {
+		return offers, nil
+	}
+
+	offers, err := c.OfferService.Search(ctx, appID, active, category, filters, options)
+	if err != nil {
+		return nil, err
+	}
+
+	c.store(ctx, appID, key, generation, offers, []string{searchTag})
+
+	return offers, nil
+}
+
+// Get caches not found offers too, the sync creating the offer drops them
+func (c *cachedService) Get(ctx context.Context, id string, appID string, options model.ReadOptions,
+) (*model.Offer, error) {
+	key := cacheKey("offer", struct {
+		ID      string
+		Options model.ReadOptions
+	}{id, options})
+
+	var offer *model.Offer
+
+	generation, ok := c.read(ctx, appID, key, &offer)
+	if ok {
+		return offer, nil
+	}
+
+	offer, err := c.OfferService.Get(ctx, id, appID, options)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []string{offerTag(id)}
+
+	if offer != nil {
+		if offer.ExternalID != nil {
+			tags = append(tags, offerTag(*offer.ExternalID))
+		}
+
+		// expanded supplementaries are part of the read, the pending ones and those not returned join it once synced
+		for _, reference := range offer.References {
+			if reference.ExternalID != "" {
+				tags = append(tags, offerTag(reference.ExternalID))
+			}
+		}
+
+		for i := range offer.SupplementaryOffers {
+			if offer.SupplementaryOffers[i].ExternalID != nil {
+				tags = append(tags, offerTag(*offer.SupplementaryOffers[i].ExternalID))
+			}
+		}
+	}
+
+	c.store(ctx, appID, key, generation, offer, tags)
+
+	return offer, nil
+}
+
+func (c *cachedService) Sync(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest,
+) (*model.SyncReport, error) {
+	report, err := c.OfferService.Sync(ctx, appID, bssSyncOffer)
+	if err != nil {
+		// the offers synced before the error are written
+		c.clear(ctx, appID)
+
+		return nil, err
+	}
+
+	c.invalidate(ctx, appID, syncTags(report.Offers))
+
+	return report, nil
+}
+
+func (c *cachedService) Reconcile(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest,
+	dryRun bool,
+) (*model.ReconcileReport, error) {
+	report, err := c.OfferService.Reconcile(ctx, appID, bssSyncOffer, dryRun)
+	if err != nil {
+		if !dryRun {
+			c.clear(ctx, appID)
+		}
+
+		return nil, err
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	results := append([]model.OfferSyncResult{}, report.Removals...)
+
+	if report.Sync != nil {
+		results = append(results, report.Sync.Offers...)
+	}
+
+	c.invalidate(ctx, appID, syncTags(results))
+
+	return report, nil
+}
+
+func (c *cachedService) SaveOffer(ctx context.Context, appID string, request model.OfferEditRequest) (*model.Offer,
+	error,
+) {
+	nOffer, err := c.OfferService.SaveOffer(ctx, appID, request)
+	if err != nil || nOffer == nil {
+		return nOffer, err
+	}
+
+	c.invalidate(ctx, appID, []string{offerTag(request.ID), searchTag})
+
+	return nOffer, nil
+}
+
+// ResolveReferences drops every read when primaries were relinked or supplementaries removed, as the resolver runs
+// periodically in the background the affected offers are not tracked
+func (c *cachedService) ResolveReferences(ctx context.Context, appID string) (*model.ReferenceReport, error) {
+	report, err := c.OfferService.ResolveReferences(ctx, appID)
+	if err != nil || report.Relinked > 0 || len(report.Orphans) > 0 {
+		c.clear(ctx, appID)
+	}
+
+	return report, err
+}
+
+// SaveExchangeRate drops every read as converted prices may change
+func (c *cachedService) SaveExchangeRate(ctx context.Context, appID string, rate model.ExchangeRate,
+) (*model.ExchangeRate, error) {
+	nRate, err := c.OfferService.SaveExchangeRate(ctx, appID, rate)
+	if err != nil {
+		return nil, err
+	}
+
+	c.clear(ctx, appID)
+
+	return nRate, nil
+}
+
+// CreateTaxRules drops every read as the taxes of the offers may change
+func (c *cachedService) CreateTaxRules(ctx context.Context, appID string, rules []model.TaxRule,
+) (*model.TaxRuleSet, error) {
+	ruleSet, err := c.OfferService.CreateTaxRules(ctx, appID, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	c.clear(ctx, appID)
+
+	return ruleSet, nil
+}
+
+// read sets value to the cached read of the key and returns the generation of the caches when the read started. The
+// in-memory reads are the values the wrapped service returned, the callers must not modify them. With a shared cache
+// the in-memory reads are dropped once another instance invalidated the shared one
+func (c *cachedService) read(ctx context.Context, appID string, key string, value interface{}) (cacheGeneration, bool) {
+	generation := cacheGeneration{local: c.currentGeneration()}
+
+	if c.shared != nil {
+		shared, err := c.shared.Generation(ctx)
+		if err != nil {
+			msg := fmt.Sprintf("[%s] reading shared cache generation error [%s]", appID, err)
+
+			c.logger.Error(msg)
+
+			// the in-memory reads can not be validated and the read is not stored
+			return cacheGeneration{shared: -1}, false
+		}
+
+		generation = c.syncSharedGeneration(shared)
+	}
+
+	if cached, ok := c.local.get(key); ok {
+		reflect.ValueOf(value).Elem().Set(reflect.ValueOf(cached))
+
+		return generation, true
+	}
+
+	if c.shared == nil {
+		return generation, false
+	}
+
+	entry, err := c.shared.Get(ctx, key)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] reading shared cache error [%s]", appID, err)
+
+		c.logger.Error(msg)
+	}
+
+	if entry == nil || json.Unmarshal(entry.Value, value) != nil {
+		return generation, false
+	}
+
+	c.mutex.Lock()
+
+	if generation.local == c.generation {
+		c.local.set(key, reflect.ValueOf(value).Elem().Interface(), entry.Tags, entry.ExpiresAt)
+	}
+
+	c.mutex.Unlock()
+
+	return generation, true
+}
+
+// store caches the read unless the caches were invalidated since the read started, it may hold stale data then. The
+// shared cache keeps the json encoding of the read, the fields it leaves out are not part of the responses either
+func (c *cachedService) store(ctx context.Context, appID string, key string, generation cacheGeneration,
+	value interface{}, tags []string,
+) {
+	if c.shared != nil {
+		shared, err := c.shared.Generation(ctx)
+		if err != nil || shared != generation.shared {
+			return
+		}
+	}
+
+	expiresAt := time.Now().Add(c.ttl)
+
+	c.mutex.Lock()
+
+	if generation.local != c.generation {
+		c.mutex.Unlock()
+
+		return
+	}
+
+	c.local.set(key, value, tags, expiresAt)
+
+	c.mutex.Unlock()
+
+	if c.shared == nil {
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	err = c.shared.Set(ctx, model.CacheEntry{
+		Key:       key,
+		Value:     data,
+		Tags:      tags,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("[%s] writing shared cache error [%s]", appID, err)
+
+		c.logger.Error(msg)
+	}
+}
+
+func (c *cachedService) currentGeneration() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.generation
+}
+
+// syncSharedGeneration drops the in-memory reads when the shared cache was invalidated since they were stored, the
+// tags other instances removed are not known
+func (c *cachedService) syncSharedGeneration(shared int64) cacheGeneration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if shared > c.sharedGeneration {
+		c.sharedGeneration = shared
+		c.generation++
+		c.local.clear()
+	}
+
+	return cacheGeneration{local: c.generation, shared: shared}
+}
+
+func (c *cachedService) invalidate(ctx context.Context, appID string, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	c.generation++
+	c.local.removeTags(tags)
+	c.mutex.Unlock()
+
+	if c.shared == nil {
+		return
+	}
+
+	if err := c.shared.RemoveTags(ctx, tags); err != nil {
+		msg := fmt.Sprintf("[%s] removing shared cache entries error [%s]", appID, err)
+
+		c.logger.Error(msg)
+	}
+}
+
+func (c *cachedService) clear(ctx context.Context, appID string) {
+	c.mutex.Lock()
+	c.generation++
+	c.local.clear()
+	c.mutex.Unlock()
+
+	if c.shared == nil {
+		return
+	}
+
+	if err := c.shared.Clear(ctx); err != nil {
+		msg := fmt.Sprintf("[%s] clearing shared cache error [%s]", appID, err)
+
+		c.logger.Error(msg)
+	}
+}
+
+// syncTags returns the tags of the reads of the offers the sync wrote, whatever the action every write changes the
+// version and update date of the offer
+func syncTags(results []model.OfferSyncResult) []string {
+	tags := make([]string, 0)
+
+	for _, result := range results {
+		if !result.Written {
+			continue
+		}
+
+		tags = append(tags, offerTag(result.ExternalID))
+	}
+
+	if len(tags) > 0 {
+		tags = append(tags, searchTag)
+	}
+
+	return tags
+}
+
+func offerTag(id string) string {
+	return "offer:" + id
+}
+
+func cacheKey(prefix string, params interface{}) string {
+	data, _ := json.Marshal(params)
+
+	return prefix + ":" + string(data)
+}
+
+func newLRUCache(capacity int) *lruCache {
+	return &lruCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (l *lruCache) get(key string) (interface{}, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	element, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := element.Value.(*lruEntry)
+
+	if time.Now().After(entry.expiresAt) {
+		l.remove(element)
+
+		return nil, false
+	}
+
+	l.order.MoveToFront(element)
+
+	return entry.value, true
+}
+
+// set stores the entry as the most recently used, evicting the least recently used one when full
+func (l *lruCache) set(key string, value interface{}, tags []string, expiresAt time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	entry := &lruEntry{key: key, value: value, tags: tags, expiresAt: expiresAt}
+
+	if element, ok := l.entries[key]; ok {
+		element.Value = entry
+		l.order.MoveToFront(element)
+
+		return
+	}
+
+	l.entries[key] = l.order.PushFront(entry)
+
+	if l.order.Len() > l.capacity {
+		l.remove(l.order.Back())
+	}
+}
+
+// removeTags removes the entries stored with any of the tags
+func (l *lruCache) removeTags(tags []string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	removed := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		removed[tag] = true
+	}
+
+	for element := l.order.Front(); element != nil; {
+		next := element.Next()
+
+		for _, tag := range element.Value.(*lruEntry).tags {
+			if removed[tag] {
+				l.remove(element)
+
+				break
+			}
+		}
+
+		element = next
+	}
+}
+
+func (l *lruCache) clear() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.entries = make(map[string]*list.Element)
+	l.order.Init()
+}
+
+func (l *lruCache) remove(element *list.Element) {
+	delete(l.entries, element.Value.(*lruEntry).key)
+	l.order.Remove(element)
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+// countingService returns the offer with the number of reads as its name and the references set, syncs return the
+// report set
+type countingService struct {
+	OfferService
+	reads      int
+	references []model.SupplementaryReference
+	report     model.SyncReport
+}
+
+func (s *countingService) Get(ctx context.Context, id string, appID string, options model.ReadOptions,
+) (*model.Offer, error) {
+	s.reads++
+
+	return &model.Offer{
+		ID:   id,
+		Name: string(rune('0' + s.reads)),
+		Price: &model.OfferPrice{
+			Fare:           model.Money{Cents: 1250, Currency: "USD"},
+			ActivationFare: model.Money{Cents: 99, Currency: "USD"},
+		},
+		References: s.references,
+	}, nil
+}
+
+func (s *countingService) Sync(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest,
+) (*model.SyncReport, error) {
+	return &s.report, nil
+}
+
+func (s *countingService) SaveOffer(ctx context.Context, appID string, request model.OfferEditRequest,
+) (*model.Offer, error) {
+	return &model.Offer{ID: request.ID}, nil
+}
+
+type memoryCache struct {
+	mutex      sync.Mutex
+	entries    map[string]model.CacheEntry
+	generation int64
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string) (*model.CacheEntry, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, nil
+	}
+
+	return &entry, nil
+}
+
+func (c *memoryCache) Set(ctx context.Context, entry model.CacheEntry) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries[entry.Key] = entry
+
+	return nil
+}
+
+func (c *memoryCache) RemoveTags(ctx context.Context, tags []string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	removed := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		removed[tag] = true
+	}
+
+	for key, entry := range c.entries {
+		for _, tag := range entry.Tags {
+			if removed[tag] {
+				delete(c.entries, key)
+
+				break
+			}
+		}
+	}
+
+	c.generation++
+
+	return nil
+}
+
+func (c *memoryCache) Clear(ctx context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries = make(map[string]model.CacheEntry)
+	c.generation++
+
+	return nil
+}
+
+func (c *memoryCache) Generation(ctx context.Context) (int64, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.generation, nil
+}
+
+func TestCachedGetKeepsPrices(t *testing.T) {
+	ctx := context.Background()
+
+	shared := &memoryCache{entries: make(map[string]model.CacheEntry)}
+
+	tests := []struct {
+		name  string
+		first *cachedService
+		then  *cachedService
+	}{
+		{
+			name:  "in memory",
+			first: NewCachedService(&countingService{}, 10, time.Minute, nil, nil),
+		},
+		{
+			name:  "shared",
+			first: NewCachedService(&countingService{}, 10, time.Minute, shared, nil),
+			then:  NewCachedService(&countingService{}, 10, time.Minute, shared, nil),
+		},
+	}
+
+	for _, test := range tests {
+		if test.then == nil {
+			test.then = test.first
+		}
+
+		read, err := test.first.Get(ctx, "a", "TEST", model.ReadOptions{})
+		if err != nil {
+			t.Fatalf("%s get error [%s]", test.name, err)
+		}
+
+		cached, err := test.then.Get(ctx, "a", "TEST", model.ReadOptions{})
+		if err != nil {
+			t.Fatalf("%s cached get error [%s]", test.name, err)
+		}
+
+		if !reflect.DeepEqual(read, cached) {
+			t.Errorf("%s cached offer = %+v, want %+v", test.name, cached, read)
+		}
+
+		if cached.Price.Fare.Cents != 1250 || cached.Price.ActivationFare.Cents != 99 {
+			t.Errorf("%s cached price = %+v, want 12.50 and 0.99", test.name, cached.Price)
+		}
+	}
+}
+
+func TestCachedGetDropsReadsInvalidatedByAnotherInstance(t *testing.T) {
+	ctx := context.Background()
+
+	shared := &memoryCache{entries: make(map[string]model.CacheEntry)}
+
+	reader := NewCachedService(&countingService{}, 10, time.Minute, shared, nil)
+	writer := NewCachedService(&countingService{}, 10, time.Minute, shared, nil)
+
+	if _, err := reader.Get(ctx, "a", "TEST", model.ReadOptions{}); err != nil {
+		t.Fatalf("get error [%s]", err)
+	}
+
+	if _, err := writer.SaveOffer(ctx, "TEST", model.OfferEditRequest{ID: "a"}); err != nil {
+		t.Fatalf("save error [%s]", err)
+	}
+
+	offer, err := reader.Get(ctx, "a", "TEST", model.ReadOptions{})
+	if err != nil {
+		t.Fatalf("get error [%s]", err)
+	}
+
+	if offer.Name != "2" {
+		t.Errorf("offer read = %s, want the second read after the other instance saved it", offer.Name)
+	}
+}
+
+func TestSyncTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []model.OfferSyncResult
+		want    []string
+	}{
+		{
+			name: "nothing written",
+			results: []model.OfferSyncResult{
+				{ExternalID: "a", Action: model.UnchangedSyncAction},
+				{ExternalID: "b", Action: model.InvalidSyncAction},
+			},
+			want: []string{},
+		},
+		{
+			name: "written whatever the action",
+			results: []model.OfferSyncResult{
+				{ExternalID: "a", Action: model.UnchangedSyncAction, Written: true},
+				{ExternalID: "b", Action: model.InvalidSyncAction, Written: true},
+				{ExternalID: "c", Action: model.UpdateSyncAction, Written: true},
+			},
+			want: []string{offerTag("a"), offerTag("b"), offerTag("c"), searchTag},
+		},
+	}
+
+	for _, test := range tests {
+		if tags := syncTags(test.results); !reflect.DeepEqual(tags, test.want) {
+			t.Errorf("%s tags = %v, want %v", test.name, tags, test.want)
+		}
+	}
+}
+
+func TestCachedGetDropsReadsOfSyncedSupplementaries(t *testing.T) {
+	ctx := context.Background()
+
+	next := &countingService{
+		references: []model.SupplementaryReference{{ExternalID: "pending", Type: model.OptionalRelationType}},
+		report: model.SyncReport{Offers: []model.OfferSyncResult{
+			{ExternalID: "pending", Action: model.CreateSyncAction, Written: true},
+		}},
+	}
+
+	c := NewCachedService(next, 10, time.Minute, nil, nil)
+
+	options := model.ReadOptions{ExpandSupplementaries: true}
+
+	if _, err := c.Get(ctx, "a", "TEST", options); err != nil {
+		t.Fatalf("get error [%s]", err)
+	}
+
+	if _, err := c.Sync(ctx, "TEST", model.BssSyncOfferRequest{}); err != nil {
+		t.Fatalf("sync error [%s]", err)
+	}
+
+	offer, err := c.Get(ctx, "a", "TEST", options)
+	if err != nil {
+		t.Fatalf("get error [%s]", err)
+	}
+
+	if offer.Name != "2" {
+		t.Errorf("offer read = %s, want the second read after its pending supplementary synced", offer.Name)
+	}
+}
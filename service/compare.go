@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+var ErrOffersNotFound = errors.New("offers not found")
+
+type comparedResource struct {
+	name  string
+	unit  string
+	value func(attributes model.DataCenterResourceAttributtes) (*float64, string)
+}
+
+// comparedResources are the rows of a comparison, storage is compared in GB and bandwidth in Mbps
+var comparedResources = []comparedResource{
+	{"cpu", "", func(a model.DataCenterResourceAttributtes) (*float64, string) {
+		return intValue(a.CPUQty), ""
+	}},
+	{"ram", "GB", func(a model.DataCenterResourceAttributtes) (*float64, string) {
+		if a.RAM == nil {
+			return nil, ""
+		}
+
+		return storageValue(a.RAM.Amount, a.RAM.Unit)
+	}},
+	{"hdd", "GB", func(a model.DataCenterResourceAttributtes) (*float64, string) {
+		if a.HDD == nil {
+			return nil, ""
+		}
+
+		return storageValue(a.HDD.Amount, a.HDD.Unit)
+	}},
+	{"bandwidth", "Mbps", func(a model.DataCenterResourceAttributtes) (*float64, string) {
+		if a.Bandwidth == nil {
+			return nil, ""
+		}
+
+		value, text := bandwidthValue(a.Bandwidth.Amount, a.Bandwidth.Unit)
+		if text == "" {
+			text = string(a.Bandwidth.Type)
+		}
+
+		return value, text
+	}},
+	{"database_quantity", "", func(a model.DataCenterResourceAttributtes) (*float64, string) {
+		if a.Database == nil {
+			return nil, ""
+		}
+
+		return intValue(&a.Database.Quantity), ""
+	}},
+	{"database_space", "GB", func(a model.DataCenterResourceAttributtes) (*float64, string) {
+		if a.Database == nil {
+			return nil, ""
+		}
+
+		return storageValue(a.Database.Amount, a.Database.Unit)
+	}},
+	{"ftp", "", func(a model.DataCenterResourceAttributtes) (*float64, string) {
+		return intValue(a.FTPQty), ""
+	}},
+	{"alias", "", func(a model.DataCenterResourceAttributtes) (*float64, string) {
+		return intValue(a.AliasQty), ""
+	}},
+	{"network_interfaces", "", func(a model.DataCenterResourceAttributtes) (*float64, string) {
+		return intValue(a.NetworkInterfaceQty), ""
+	}},
+	{"public_ips", "", func(a model.DataCenterResourceAttributtes) (*float64, string) {
+		if a.PublicIPAddress == nil {
+			return nil, ""
+		}
+
+		d, err := strconv.ParseFloat(*a.PublicIPAddress, 64)
+		if err != nil {
+			return nil, *a.PublicIPAddress
+		}
+
+		return &d, ""
+	}},
+}
+
+// Compare builds a side by side matrix of the data center resources of the offers, ids can be ids or external ids
+func (s *service) Compare(ctx context.Context, appID string, ids []string, currency string) (*model.Comparison, error) {
+	comparison, err := s.compare(ctx, ids, currency)
+	if err != nil {
+		if errors.Is(err, ErrOffersNotFound) || errors.Is(err, ErrUnknownCurrency) {
+			return nil, err
+		}
+
+		msg := fmt.Sprintf("[%s] comparing offers %v error [%s]", appID, ids, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	return comparison, nil
+}
+
+func (s *service) compare(ctx context.Context, ids []string, currency string) (*model.Comparison, error) {
+	found, err := s.repository.GetByIDList(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	offers := make([]model.Offer, 0, len(ids))
+	missing := make([]string, 0)
+
+	for _, id := range ids {
+		offer, ok := findOffer(found, id)
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+
+		offers = append(offers, offer)
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w %v", ErrOffersNotFound, missing)
+	}
+
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		currency = s.offerPrice(offers[0]).Fare.Currency
+	}
+
+	rates, err := s.exchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := rates[currency]; !ok {
+		return nil, fmt.Errorf("%w [%s]", ErrUnknownCurrency, currency)
+	}
+
+	comparison := model.Comparison{
+		Currency: currency,
+		Offers:   make([]model.ComparedOffer, 0, len(offers)),
+		Rows:     make([]model.ComparisonRow, 0, len(comparedResources)),
+	}
+
+	for _, offer := range offers {
+		fare := s.offerPrice(offer).Fare
+
+		if _, ok := rates[fare.Currency]; !ok {
+			return nil, fmt.Errorf("%w [%s] of offer [%s]", ErrUnknownCurrency, fare.Currency, offer.ID)
+		}
+
+		comparison.Offers = append(comparison.Offers, model.ComparedOffer{
+			ID:         offer.ID,
+			ExternalID: offer.ExternalID,
+			Name:       offer.Name,
+			Type:       offer.Type,
+			Fare:       convertMoney(fare, rates, currency),
+		})
+	}
+
+	for _, resource := range comparedResources {
+		row := model.ComparisonRow{
+			Resource: resource.name,
+			Unit:     resource.unit,
+			Cells:    make([]model.ComparisonCell, 0, len(offers)),
+		}
+
+		present := false
+
+		for i, offer := range offers {
+			var cell model.ComparisonCell
+
+			if offer.DataCenterResourceAttributtes != nil {
+				cell.Value, cell.Text = resource.value(*offer.DataCenterResourceAttributtes)
+			}
+
+			if cell.Value != nil && *cell.Value > 0 {
+				perUnit := new(big.Rat).SetFloat64(*cell.Value)
+				perUnit.Quo(comparison.Offers[i].Fare.Rat(), perUnit)
+
+				pricePerUnit := model.NewMoney(perUnit, currency)
+				cell.PricePerUnit = &pricePerUnit
+			}
+
+			present = present || cell.Value != nil || cell.Text != ""
+
+			row.Cells = append(row.Cells, cell)
+		}
+
+		if !present {
+			continue
+		}
+
+		row.Different = cellsDiffer(row.Cells)
+
+		comparison.Rows = append(comparison.Rows, row)
+	}
+
+	return &comparison, nil
+}
+
+func findOffer(offers []model.Offer, id string) (model.Offer, bool) {
+	for i := range offers {
+		if offers[i].ID == id || (offers[i].ExternalID != nil && *offers[i].ExternalID == id) {
+			return offers[i], true
+		}
+	}
+
+	return model.Offer{}, false
+}
+
+func cellsDiffer(cells []model.ComparisonCell) bool {
+	for i := 1; i < len(cells); i++ {
+		if cells[i].Text != cells[0].Text || (cells[i].Value == nil) != (cells[0].Value == nil) {
+			return true
+		}
+
+		if cells[i].Value != nil && *cells[i].Value != *cells[0].Value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func intValue(v *int) *float64 {
+	if v == nil {
+		return nil
+	}
+
+	d := float64(*v)
+
+	return &d
+}
+
+// storageValue returns the amount in GB, amounts in an unknown unit are returned as text
+func storageValue(amount float64, unit string) (*float64, string) {
+	bytes, ok := model.StorageBytes(amount, unit)
+	if !ok {
+		return nil, fmt.Sprintf("%g %s", amount, unit)
+	}
+
+	d := model.ConvertStorage(bytes, "GB")
+
+	return &d, ""
+}
+
+// bandwidthValue returns the amount in Mbps, amounts in an unknown unit are returned as text
+func bandwidthValue(amount float64, unit string) (*float64, string) {
+	bitsPerSecond, ok := model.BandwidthBitsPerSecond(amount, unit)
+	if !ok {
+		return nil, fmt.Sprintf("%g %s", amount, unit)
+	}
+
+	d := model.ConvertBandwidth(bitsPerSecond, "Mbps")
+
+	return &d, ""
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"github.com/srrmendez/private-api-offers/repository"
+)
+
+func TestCompareNormalizesUnitsAndCurrencies(t *testing.T) {
+	ctx := context.Background()
+
+	s := &service{
+		repository:             repository.NewMemoryRepository(),
+		exchangeRateRepository: &memoryExchangeRates{rates: []model.ExchangeRate{{Currency: "EUR", Rate: "0.5"}}},
+		settings:               Settings{DefaultCurrency: "USD"},
+	}
+
+	cpu := 2
+
+	small := pricedOffer("", 1000, 0, "USD")
+	small.ExternalID = stringPtr("small")
+	small.Name = "Small"
+	small.DataCenterResourceAttributtes = &model.DataCenterResourceAttributtes{
+		CPUQty: &cpu,
+		RAM:    &model.RAM{Amount: 2, Unit: "GB"},
+		HDD:    &model.HDD{Amount: 1024, Unit: "GB"},
+	}
+
+	large := pricedOffer("", 2000, 0, "EUR")
+	large.ExternalID = stringPtr("large")
+	large.Name = "Large"
+	large.DataCenterResourceAttributtes = &model.DataCenterResourceAttributtes{
+		CPUQty: &cpu,
+		RAM:    &model.RAM{Amount: 4096, Unit: "MB"},
+		HDD:    &model.HDD{Amount: 1, Unit: "TB"},
+	}
+
+	for _, offer := range []model.Offer{small, large} {
+		if _, err := s.repository.UpsertByExternalID(ctx, offer); err != nil {
+			t.Fatalf("upsert error [%s]", err)
+		}
+	}
+
+	comparison, err := s.compare(ctx, []string{"small", "large"}, "")
+	if err != nil {
+		t.Fatalf("compare error [%s]", err)
+	}
+
+	if comparison.Currency != "USD" || comparison.Offers[1].Fare != (model.Money{Cents: 4000, Currency: "USD"}) {
+		t.Errorf("comparison in %s with fares %+v, want USD and the large offer at 40.00", comparison.Currency,
+			comparison.Offers)
+	}
+
+	type cell struct {
+		value        float64
+		pricePerUnit int64
+	}
+
+	tests := []struct {
+		resource  string
+		cells     []cell
+		different bool
+	}{
+		{resource: "cpu", cells: []cell{{2, 500}, {2, 2000}}},
+		{resource: "ram", cells: []cell{{2, 500}, {4, 1000}}, different: true},
+		{resource: "hdd", cells: []cell{{1024, 1}, {1024, 4}}},
+	}
+
+	if len(comparison.Rows) != len(tests) {
+		t.Fatalf("rows = %+v, want %d rows", comparison.Rows, len(tests))
+	}
+
+	for i, test := range tests {
+		row := comparison.Rows[i]
+
+		if row.Resource != test.resource || row.Different != test.different {
+			t.Errorf("row %s different = %t, want %s different %t", row.Resource, row.Different, test.resource,
+				test.different)
+		}
+
+		for j, want := range test.cells {
+			got := row.Cells[j]
+
+			if got.Value == nil || *got.Value != want.value || got.PricePerUnit == nil ||
+				got.PricePerUnit.Cents != want.pricePerUnit {
+				t.Errorf("%s cell %d = %+v, want %g at %d cents per unit", test.resource, j, got, want.value,
+					want.pricePerUnit)
+			}
+		}
+	}
+
+	if _, err = s.compare(ctx, []string{"small", "missing"}, ""); !errors.Is(err, ErrOffersNotFound) {
+		t.Errorf("compare with a missing offer error [%v], want offers not found", err)
+	}
+
+	if _, err = s.compare(ctx, []string{"small", "large"}, "GBP"); !errors.Is(err, ErrUnknownCurrency) {
+		t.Errorf("compare in GBP error [%v], want unknown currency", err)
+	}
+}
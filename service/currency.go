@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+var (
+	ErrUnknownCurrency     = errors.New("currency has no exchange rate")
+	ErrInvalidExchangeRate = errors.New("exchange rate must be a positive decimal for a three letter currency code")
+)
+
+// SeedExchangeRates stores the rates missing from the exchange rate table, rates edited through the api are never
+// overwritten
+func (s *service) SeedExchangeRates(ctx context.Context, rates map[string]string) error {
+	for currency, rate := range rates {
+		stored, err := s.exchangeRateRepository.Get(ctx, currency)
+		if err != nil {
+			return err
+		}
+
+		if stored != nil {
+			continue
+		}
+
+		exchangeRate, err := checkExchangeRate(model.ExchangeRate{Currency: currency, Rate: rate})
+		if err != nil {
+			return fmt.Errorf("exchange rate [%s] [%w]", currency, err)
+		}
+
+		if _, err = s.exchangeRateRepository.Upsert(ctx, *exchangeRate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *service) GetExchangeRates(ctx context.Context, appID string) ([]model.ExchangeRate, error) {
+	rates, err := s.exchangeRateRepository.All(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting exchange rates error [%s]", appID, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+func (s *service) SaveExchangeRate(ctx context.Context, appID string, rate model.ExchangeRate) (*model.ExchangeRate, error) {
+	exchangeRate, err := checkExchangeRate(rate)
+	if err != nil {
+		return nil, err
+	}
+
+	nRate, err := s.exchangeRateRepository.Upsert(ctx, *exchangeRate)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] saving exchange rate [%s] error [%s]", appID, rate.Currency, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	return nRate, nil
+}
+
+// convertOffers sets the converted price of the offers and their expanded supplementaries, offers priced in a
+// currency without rate are left unconverted
+func (s *service) convertOffers(ctx context.Context, offers []model.Offer, currency string) error {
+	rates, err := s.exchangeRates(ctx)
+	if err != nil {
+		return err
+	}
+
+	currency = strings.ToUpper(currency)
+
+	if _, ok := rates[currency]; !ok {
+		return fmt.Errorf("%w [%s]", ErrUnknownCurrency, currency)
+	}
+
+	for i := range offers {
+		offers[i].ConvertedPrice = convertPrice(offers[i].Price, rates, currency)
+
+		for j := range offers[i].SupplementaryOffers {
+			offers[i].SupplementaryOffers[j].ConvertedPrice = convertPrice(offers[i].SupplementaryOffers[j].Price, rates,
+				currency)
+		}
+	}
+
+	return nil
+}
+
+// exchangeRates returns the units of each currency per unit of the default currency
+func (s *service) exchangeRates(ctx context.Context) (map[string]*big.Rat, error) {
+	stored, err := s.exchangeRateRepository.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make(map[string]*big.Rat, len(stored)+1)
+
+	for i := range stored {
+		rate, ok := new(big.Rat).SetString(stored[i].Rate)
+		if !ok || rate.Sign() <= 0 {
+			continue
+		}
+
+		rates[stored[i].Currency] = rate
+	}
+
+	rates[s.settings.DefaultCurrency] = big.NewRat(1, 1)
+
+	return rates, nil
+}
+
+func convertPrice(price *model.OfferPrice, rates map[string]*big.Rat, currency string) *model.OfferPrice {
+	if price == nil {
+		return nil
+	}
+
+	if _, ok := rates[price.Fare.Currency]; !ok {
+		return nil
+	}
+
+	return &model.OfferPrice{
+		Fare:           convertMoney(price.Fare, rates, currency),
+		ActivationFare: convertMoney(price.ActivationFare, rates, currency),
+	}
+}
+
+func convertMoney(m model.Money, rates map[string]*big.Rat, currency string) model.Money {
+	amount := m.Rat()
+
+	amount.Mul(amount, rates[currency])
+	amount.Quo(amount, rates[m.Currency])
+
+	return model.NewMoney(amount, currency)
+}
+
+func checkExchangeRate(rate model.ExchangeRate) (*model.ExchangeRate, error) {
+	rate.Currency = strings.ToUpper(rate.Currency)
+
+	r, ok := new(big.Rat).SetString(rate.Rate)
+	if !ok || r.Sign() <= 0 || len(rate.Currency) != 3 {
+		return nil, ErrInvalidExchangeRate
+	}
+
+	return &rate, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+// fields maintained by the repository and the diagnostics, which are refreshed on their own, never count as a change
+var ignoredDiffFields = map[string]bool{
+	"_id":         true,
+	"created_at":  true,
+	"updated_at":  true,
+	"diagnostics": true,
+	"version":     true,
+}
+
+// diffOffers returns the fields that differ between the stored offer and the mapped one,
+// named after their bson path so they match what is persisted
+func diffOffers(stored model.Offer, mapped model.Offer) []model.FieldChange {
+	changes := make([]model.FieldChange, 0)
+
+	diffStruct("", reflect.ValueOf(stored), reflect.ValueOf(mapped), &changes)
+
+	return changes
+}
+
+func diffStruct(prefix string, stored reflect.Value, mapped reflect.Value, changes *[]model.FieldChange) {
+	t := stored.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("bson"), ",")[0]
+		if name == "" {
+			name = strings.ToLower(t.Field(i).Name)
+		}
+
+		if name == "-" || ignoredDiffFields[name] {
+			continue
+		}
+
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		sv := stored.Field(i)
+		mv := mapped.Field(i)
+
+		if sv.Kind() == reflect.Ptr && sv.Type().Elem().Kind() == reflect.Struct {
+			if sv.IsNil() && mv.IsNil() {
+				continue
+			}
+
+			diffStruct(name, derefOrZero(sv), derefOrZero(mv), changes)
+
+			continue
+		}
+
+		if sv.Kind() == reflect.Slice && sv.Len() == 0 && mv.Len() == 0 {
+			continue
+		}
+
+		if reflect.DeepEqual(sv.Interface(), mv.Interface()) {
+			continue
+		}
+
+		*changes = append(*changes, model.FieldChange{
+			Field:  name,
+			Stored: indirectValue(sv),
+			Mapped: indirectValue(mv),
+		})
+	}
+}
+
+func derefOrZero(v reflect.Value) reflect.Value {
+	if v.IsNil() {
+		return reflect.Zero(v.Type().Elem())
+	}
+
+	return v.Elem()
+}
+
+func indirectValue(v reflect.Value) interface{} {
+	if v.Kind() != reflect.Ptr {
+		return v.Interface()
+	}
+
+	if v.IsNil() {
+		return nil
+	}
+
+	return v.Elem().Interface()
+}
+
+func diagnosticsDiffer(stored []model.Diagnostic, mapped []model.Diagnostic) bool {
+	if len(stored) == 0 && len(mapped) == 0 {
+		return false
+	}
+
+	return !reflect.DeepEqual(stored, mapped)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+// the fakes keep the catalogs and service types the sync reads and writes next to the memory offer repositories
+
+type memoryCatalogs struct {
+	mutex    sync.Mutex
+	catalogs map[string]model.Catalog
+}
+
+func (r *memoryCatalogs) All(ctx context.Context) ([]model.Catalog, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	catalogs := make([]model.Catalog, 0, len(r.catalogs))
+
+	for _, catalog := range r.catalogs {
+		catalogs = append(catalogs, catalog)
+	}
+
+	return catalogs, nil
+}
+
+func (r *memoryCatalogs) Get(ctx context.Context, id string) (*model.Catalog, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	catalog, ok := r.catalogs[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &catalog, nil
+}
+
+func (r *memoryCatalogs) Upsert(ctx context.Context, catalog model.Catalog) (*model.Catalog, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.catalogs == nil {
+		r.catalogs = make(map[string]model.Catalog)
+	}
+
+	r.catalogs[catalog.ID] = catalog
+
+	return &catalog, nil
+}
+
+type memoryServiceTypes struct {
+	serviceTypes []model.ServiceType
+}
+
+func (r *memoryServiceTypes) All(ctx context.Context) ([]model.ServiceType, error) {
+	return r.serviceTypes, nil
+}
+
+func (r *memoryServiceTypes) Get(ctx context.Context, code string) (*model.ServiceType, error) {
+	for i := range r.serviceTypes {
+		if r.serviceTypes[i].Code == code {
+			return &r.serviceTypes[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *memoryServiceTypes) Upsert(ctx context.Context, serviceType model.ServiceType) (*model.ServiceType, error) {
+	r.serviceTypes = append(r.serviceTypes, serviceType)
+
+	return &serviceType, nil
+}
+
+func (r *memoryServiceTypes) Remove(ctx context.Context, code string) error {
+	return nil
+}
+
+type memoryExchangeRates struct {
+	rates []model.ExchangeRate
+}
+
+func (r *memoryExchangeRates) All(ctx context.Context) ([]model.ExchangeRate, error) {
+	return r.rates, nil
+}
+
+func (r *memoryExchangeRates) Get(ctx context.Context, currency string) (*model.ExchangeRate, error) {
+	for i := range r.rates {
+		if r.rates[i].Currency == currency {
+			return &r.rates[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *memoryExchangeRates) Upsert(ctx context.Context, rate model.ExchangeRate) (*model.ExchangeRate, error) {
+	r.rates = append(r.rates, rate)
+
+	return &rate, nil
+}
+
+type memoryQuotes struct {
+	quotes map[string]model.Quote
+}
+
+func (r *memoryQuotes) Get(ctx context.Context, id string) (*model.Quote, error) {
+	quote, ok := r.quotes[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return &quote, nil
+}
+
+func (r *memoryQuotes) Insert(ctx context.Context, quote model.Quote) (*model.Quote, error) {
+	if r.quotes == nil {
+		r.quotes = make(map[string]model.Quote)
+	}
+
+	quote.ID = fmt.Sprintf("quote-%d", len(r.quotes)+1)
+	r.quotes[quote.ID] = quote
+
+	return &quote, nil
+}
+
+// memoryTaxRules has no rule set unless one is inserted, quotes are then untaxed
+type memoryTaxRules struct {
+	ruleSets []model.TaxRuleSet
+}
+
+func (r *memoryTaxRules) Latest(ctx context.Context) (*model.TaxRuleSet, error) {
+	if len(r.ruleSets) == 0 {
+		return nil, nil
+	}
+
+	return &r.ruleSets[len(r.ruleSets)-1], nil
+}
+
+func (r *memoryTaxRules) Get(ctx context.Context, version int) (*model.TaxRuleSet, error) {
+	for i := range r.ruleSets {
+		if r.ruleSets[i].Version == version {
+			return &r.ruleSets[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *memoryTaxRules) Insert(ctx context.Context, ruleSet model.TaxRuleSet) (*model.TaxRuleSet, error) {
+	r.ruleSets = append(r.ruleSets, ruleSet)
+
+	return &ruleSet, nil
+}
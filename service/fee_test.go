@@ -0,0 +1,129 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+func TestMapFeesPrecedence(t *testing.T) {
+	s := &service{settings: Settings{DefaultCurrency: "USD", UsageAttribute: "usageAmount"}}
+
+	tests := []struct {
+		name            string
+		bssOffer        model.BssOffer
+		attributes      []model.BssAttribute
+		wantFees        []model.FeeComponent
+		wantDiagnostics []model.DiagnosticType
+	}{
+		{
+			name:     "top level fees",
+			bssOffer: model.BssOffer{OneOfFee: 5, MontlyFee: 12.5, TopupFee: "3.10"},
+			wantFees: []model.FeeComponent{
+				{Type: model.OneOffFee, Amount: model.Money{Cents: 500, Currency: "USD"}, Source: "oneoff_fee"},
+				{Type: model.MonthlyFee, Amount: model.Money{Cents: 1250, Currency: "USD"}, Source: "monthly_fee"},
+				{Type: model.TopupFee, Amount: model.Money{Cents: 310, Currency: "USD"}, Source: "topupFee"},
+			},
+		},
+		{
+			name:       "amount attribute when monthly_fee is missing",
+			attributes: []model.BssAttribute{{Code: "amount", Value: "9.99"}},
+			wantFees: []model.FeeComponent{
+				{Type: model.MonthlyFee, Amount: model.Money{Cents: 999, Currency: "USD"}, Source: "amount"},
+			},
+		},
+		{
+			name:       "monthly_fee wins over a conflicting amount attribute",
+			bssOffer:   model.BssOffer{MontlyFee: 10},
+			attributes: []model.BssAttribute{{Code: "amount", Value: "9.99"}},
+			wantFees: []model.FeeComponent{
+				{Type: model.MonthlyFee, Amount: model.Money{Cents: 1000, Currency: "USD"}, Source: "monthly_fee"},
+			},
+			wantDiagnostics: []model.DiagnosticType{model.ConflictingFeeDiagnostic},
+		},
+		{
+			name:       "matching amount attribute is no conflict",
+			bssOffer:   model.BssOffer{MontlyFee: 10},
+			attributes: []model.BssAttribute{{Code: "amount", Value: "10.00"}},
+			wantFees: []model.FeeComponent{
+				{Type: model.MonthlyFee, Amount: model.Money{Cents: 1000, Currency: "USD"}, Source: "monthly_fee"},
+			},
+		},
+		{
+			name:       "usage attribute",
+			attributes: []model.BssAttribute{{Code: "usageAmount", Value: "0.05"}},
+			wantFees: []model.FeeComponent{
+				{Type: model.UsageFee, Amount: model.Money{Cents: 5, Currency: "USD"}, Source: "usageAmount"},
+			},
+		},
+		{
+			name:            "invalid amount attribute",
+			bssOffer:        model.BssOffer{MontlyFee: 10},
+			attributes:      []model.BssAttribute{{Code: "amount", Value: "ten"}},
+			wantFees:        []model.FeeComponent{{Type: model.MonthlyFee, Amount: model.Money{Cents: 1000, Currency: "USD"}, Source: "monthly_fee"}},
+			wantDiagnostics: []model.DiagnosticType{model.InvalidValueDiagnostic},
+		},
+		{
+			name:            "invalid topup and usage",
+			bssOffer:        model.BssOffer{TopupFee: "free"},
+			attributes:      []model.BssAttribute{{Code: "usageAmount", Value: "1,5"}},
+			wantFees:        []model.FeeComponent{},
+			wantDiagnostics: []model.DiagnosticType{model.InvalidValueDiagnostic, model.InvalidValueDiagnostic},
+		},
+		{
+			name:     "zero fees are not listed",
+			bssOffer: model.BssOffer{TopupFee: "0"},
+			wantFees: []model.FeeComponent{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bssOffer := test.bssOffer
+
+			if test.attributes != nil {
+				bssOffer.Attributes = &model.BssAttributeList{Attribute: test.attributes}
+			}
+
+			offer, err := s.mapBssOfferToOffer(bssOffer)
+			if err != nil {
+				t.Fatalf("mapping error [%s]", err)
+			}
+
+			if !reflect.DeepEqual(offer.Fees, test.wantFees) {
+				t.Errorf("fees = %+v, want %+v", offer.Fees, test.wantFees)
+			}
+
+			diagnostics := make([]model.DiagnosticType, 0)
+
+			for _, diagnostic := range offer.Diagnostics {
+				diagnostics = append(diagnostics, diagnostic.Type)
+			}
+
+			if len(diagnostics) != len(test.wantDiagnostics) ||
+				len(diagnostics) > 0 && !reflect.DeepEqual(diagnostics, test.wantDiagnostics) {
+				t.Errorf("diagnostics = %v, want %v", diagnostics, test.wantDiagnostics)
+			}
+		})
+	}
+}
+
+func TestMapFeesWithoutUsageAttribute(t *testing.T) {
+	s := &service{settings: Settings{DefaultCurrency: "USD"}}
+
+	offer, err := s.mapBssOfferToOffer(model.BssOffer{
+		Attributes: &model.BssAttributeList{Attribute: []model.BssAttribute{{Code: "usageAmount", Value: "0.05"}}},
+	})
+	if err != nil {
+		t.Fatalf("mapping error [%s]", err)
+	}
+
+	if len(offer.Fees) != 0 {
+		t.Errorf("fees = %+v, want none", offer.Fees)
+	}
+
+	if len(offer.Diagnostics) != 1 || offer.Diagnostics[0].Type != model.UnknownAttributeDiagnostic {
+		t.Errorf("diagnostics = %+v, want an unknown attribute", offer.Diagnostics)
+	}
+}
@@ -5,44 +5,61 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
 
-	"github.com/srrmendez/private-api-offers/conf"
 	"github.com/srrmendez/private-api-offers/model"
 	"github.com/srrmendez/private-api-offers/repository"
 	log "github.com/srrmendez/services-interface-tools/pkg/logger"
 	"github.com/srrmendez/services-interface-tools/pkg/tracking"
 )
 
-func NewService(repository repository.OfferRepository, supplementary repository.OfferRepository, logger log.Log,
-	confCategories map[string]conf.Category, trackingClient tracking.TrackingClient,
+var (
+	ErrReconcileThreshold = errors.New("reconcile removal threshold exceeded")
+	ErrVersionConflict    = repository.ErrVersionConflict
+	ErrPriceEdit          = errors.New("prices are set by the commercial system and can not be edited")
+	ErrUnknownUnit        = errors.New("unit is not recognized")
+	ErrInvalidDate        = errors.New("date is not recognized")
+)
+
+// syncRetries is how many times an offer is synced again after it was modified while syncing it
+const syncRetries = 3
+
+func NewService(repository repository.OfferRepository, supplementary repository.OfferRepository,
+	catalogRepository repository.CatalogRepository, serviceTypeRepository repository.ServiceTypeRepository,
+	exchangeRateRepository repository.ExchangeRateRepository, taxRuleRepository repository.TaxRuleRepository,
+	quoteRepository repository.QuoteRepository, logger log.Log, trackingClient tracking.TrackingClient, settings Settings,
 ) *service {
 	return &service{
 		repository:              repository,
 		supplementaryRepository: supplementary,
+		catalogRepository:       catalogRepository,
 		logger:                  logger,
-		confCategories:          confCategories,
+		serviceTypeRepository:   serviceTypeRepository,
+		serviceTypes:            make(map[string]model.ServiceType),
+		exchangeRateRepository:  exchangeRateRepository,
+		taxRuleRepository:       taxRuleRepository,
+		quoteRepository:         quoteRepository,
 		trackingClient:          trackingClient,
+		settings:                settings,
 	}
 }
 
-func (s *service) Search(ctx context.Context, appID string, active *bool, category *model.CategoryType) ([]model.Offer, error) {
-	if active == nil && category == nil {
-		offers, err := s.repository.All(ctx)
-		if err != nil {
-			msg := fmt.Sprintf("[%s] searching offers error [%s]", appID, err)
-
-			s.logger.Error(msg)
+func (s *service) Search(ctx context.Context, appID string, active *bool, category *model.CategoryType,
+	filters model.SearchFilters, options model.ReadOptions,
+) ([]model.Offer, error) {
+	offers, err := s.search(ctx, active, category, filters)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] searching offers error [%s]", appID, err)
 
-			return nil, err
-		}
+		s.logger.Error(msg)
 
-		return offers, nil
+		return nil, err
 	}
 
-	offers, err := s.repository.Search(ctx, active, category)
-	if err != nil {
-		msg := fmt.Sprintf("[%s] searching offers error [%s]", appID, err)
+	if err = s.applyReadOptions(ctx, offers, options); err != nil {
+		msg := fmt.Sprintf("[%s] reading offers error [%s]", appID, err)
 
 		s.logger.Error(msg)
 
@@ -52,11 +69,23 @@ func (s *service) Search(ctx context.Context, appID string, active *bool, catego
 	return offers, nil
 }
 
-func (s *service) Sync(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest) error {
+func (s *service) search(ctx context.Context, active *bool, category *model.CategoryType,
+	filters model.SearchFilters,
+) ([]model.Offer, error) {
+	if active == nil && category == nil && filters == (model.SearchFilters{}) {
+		return s.repository.All(ctx)
+	}
+
+	return s.repository.Search(ctx, active, category, filters)
+}
+
+func (s *service) Sync(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest) (*model.SyncReport, error) {
 	d, _ := json.Marshal(bssSyncOffer)
 
+	trackingID := tracking.NewTrackingID()
+
 	s.trackingClient.Send(tracking.Request{
-		TrackingID:  tracking.NewTrackingID(),
+		TrackingID:  trackingID,
 		Source:      "BSS",
 		Flow:        "SYNC_OFFERS",
 		ContentType: tracking.JSONContent,
@@ -67,486 +96,1933 @@ func (s *service) Sync(ctx context.Context, appID string, bssSyncOffer model.Bss
 		},
 	})
 
-	if err := s.sync(context.Background(), appID, bssSyncOffer); err != nil {
+	if err := s.loadServiceTypes(ctx); err != nil {
+		msg := fmt.Sprintf("[%s] loading service types error [%s]", appID, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	report, err := s.sync(context.Background(), appID, bssSyncOffer)
+	if err != nil {
 		msg := fmt.Sprintf("[%s] syncing offers error [%s]", appID, err)
 
 		s.logger.Error(msg)
 
-		return err
+		return nil, err
 	}
 
-	return nil
+	d, _ = json.Marshal(report)
+
+	s.trackingClient.Send(tracking.Request{
+		TrackingID:  trackingID,
+		Source:      "BSS",
+		Flow:        "SYNC_OFFERS_REPORT",
+		ContentType: tracking.JSONContent,
+		Action:      tracking.ActionRequest,
+		Message: &tracking.Message{
+			Endpoint: "",
+			Body:     string(d),
+		},
+	})
+
+	return report, nil
 }
 
-func (s *service) sync(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest) error {
-	for i := range bssSyncOffer.SyncOffers {
-		if bssSyncOffer.SyncOffers[i].Offer.PrimaryFlag == "1" {
-			if err := s.syncPrimaryOffer(ctx, bssSyncOffer.SyncOffers[i].Offer); err != nil {
-				msg := fmt.Sprintf("syncing offer [%s] [%s]", bssSyncOffer.SyncOffers[i].Offer.Name, err)
-				s.logger.Error(msg)
+func (s *service) sync(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest) (*model.SyncReport, error) {
+	report := model.SyncReport{
+		Offers: make([]model.OfferSyncResult, 0, len(bssSyncOffer.SyncOffers)),
+	}
 
-				return err
-			}
+	if err := s.syncCatalogs(ctx, bssSyncOffer); err != nil {
+		msg := fmt.Sprintf("syncing catalogs [%s]", err)
+		s.logger.Error(msg)
 
-			continue
-		}
+		return nil, err
+	}
 
-		if err := s.syncSupplementaryOffer(ctx, bssSyncOffer.SyncOffers[i].Offer); err != nil {
-			msg := fmt.Sprintf("syncing offer [%s] [%s]", bssSyncOffer.SyncOffers[i].Offer.Name, err)
+	for _, bssOffer := range bundlesLast(bssSyncOffer.SyncOffers) {
+		result, err := s.syncOffer(ctx, bssOffer)
+		if err != nil {
+			msg := fmt.Sprintf("syncing offer [%s] [%s]", bssOffer.Name, err)
 			s.logger.Error(msg)
 
-			return err
+			return nil, err
 		}
+
+		report.Offers = append(report.Offers, *result)
 	}
 
-	return nil
+	return &report, nil
 }
 
-func (s *service) syncPrimaryOffer(ctx context.Context, bssOffer model.BssOffer) error {
-	if bssOffer.Status == model.SuspendBssStatus || bssOffer.Status == model.RetirementBssStatus {
-		return s.repository.RemoveByExternalID(ctx, bssOffer.ID)
-	}
-
-	offer, err := s.repository.GetByExternalID(ctx, bssOffer.ID)
+// syncCatalogs stores the catalogs referenced by the offers, only new or renamed ones are written
+func (s *service) syncCatalogs(ctx context.Context, bssSyncOffer model.BssSyncOfferRequest) error {
+	catalogs, err := s.catalogRepository.All(ctx)
 	if err != nil {
 		return err
 	}
 
-	nOffer, err := s.mapBssOfferToOffer(bssOffer)
-	if err != nil {
-		return err
-	}
+	stored := make(map[string]string, len(catalogs))
 
-	if offer != nil {
-		nOffer.ID = offer.ID
-		nOffer.CreatedAt = offer.CreatedAt
-		nOffer.UpdatedAt = offer.UpdatedAt
+	for i := range catalogs {
+		stored[catalogs[i].ID] = catalogs[i].Name
 	}
 
-	if bssOffer.Relationships != nil && len(bssOffer.Relationships.Attached) > 0 {
-		nOffer.Supplementaries = make([]string, 0, len(bssOffer.Relationships.Attached))
-
-		for i := range bssOffer.Relationships.Attached {
-			supOffer, err := s.supplementaryRepository.GetByExternalID(ctx, bssOffer.Relationships.Attached[i].ID)
-			if err != nil {
-				return nil
-			}
-
-			if supOffer != nil {
-				nOffer.Supplementaries = append(nOffer.Supplementaries, supOffer.ID)
+	for i := range bssSyncOffer.SyncOffers {
+		for _, catalog := range bssSyncOffer.SyncOffers[i].Offer.Catalogs.Catalogs {
+			if name, ok := stored[catalog.ID]; ok && name == catalog.Name {
 				continue
 			}
 
-			sOffer, err := s.supplementaryRepository.Upsert(ctx, model.Offer{
-				ExternalID: &bssOffer.Relationships.Attached[i].ID,
-			})
-			if err != nil {
+			if _, err = s.catalogRepository.Upsert(ctx, model.Catalog{ID: catalog.ID, Name: catalog.Name}); err != nil {
 				return err
 			}
 
-			nOffer.Supplementaries = append(nOffer.Supplementaries, sOffer.ID)
+			stored[catalog.ID] = catalog.Name
 		}
 	}
 
-	if _, err = s.repository.Upsert(ctx, *nOffer); err != nil {
-		return err
-	}
-
 	return nil
 }
 
-func (s *service) syncSupplementaryOffer(ctx context.Context, bssOffer model.BssOffer) error {
-	if bssOffer.Status == model.SuspendBssStatus || bssOffer.Status == model.RetirementBssStatus {
-		return s.supplementaryRepository.RemoveByExternalID(ctx, bssOffer.ID)
+// bundlesLast orders the offers so bundles are synced once the offers they are composed of are stored
+func bundlesLast(syncOffers []model.BSSOfferRequest) []model.BssOffer {
+	offers := make([]model.BssOffer, 0, len(syncOffers))
+	bundles := make([]model.BssOffer, 0)
+
+	for i := range syncOffers {
+		if syncOffers[i].Offer.BundleFlag == "1" {
+			bundles = append(bundles, syncOffers[i].Offer)
+			continue
+		}
+
+		offers = append(offers, syncOffers[i].Offer)
 	}
 
-	offer, err := s.supplementaryRepository.GetByExternalID(ctx, bssOffer.ID)
-	if err != nil {
-		return err
+	return append(offers, bundles...)
+}
+
+func (s *service) Reconcile(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest, dryRun bool,
+) (*model.ReconcileReport, error) {
+	d, _ := json.Marshal(bssSyncOffer)
+
+	trackingID := tracking.NewTrackingID()
+
+	s.trackingClient.Send(tracking.Request{
+		TrackingID:  trackingID,
+		Source:      "BSS",
+		Flow:        "RECONCILE_OFFERS",
+		ContentType: tracking.JSONContent,
+		Action:      tracking.ActionRequest,
+		Message: &tracking.Message{
+			Endpoint: "",
+			Body:     string(d),
+		},
+	})
+
+	if err := s.loadServiceTypes(ctx); err != nil {
+		msg := fmt.Sprintf("[%s] loading service types error [%s]", appID, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
 	}
 
-	nOffer, err := s.mapBssOfferToOffer(bssOffer)
+	report, err := s.reconcile(context.Background(), appID, bssSyncOffer, dryRun)
 	if err != nil {
-		return err
-	}
+		msg := fmt.Sprintf("[%s] reconciling offers error [%s]", appID, err)
 
-	if offer != nil {
-		nOffer.ID = offer.ID
-		nOffer.CreatedAt = offer.CreatedAt
-		nOffer.UpdatedAt = offer.UpdatedAt
-	}
+		s.logger.Error(msg)
 
-	if _, err = s.supplementaryRepository.Upsert(ctx, *nOffer); err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	d, _ = json.Marshal(report)
+
+	s.trackingClient.Send(tracking.Request{
+		TrackingID:  trackingID,
+		Source:      "BSS",
+		Flow:        "RECONCILE_OFFERS_REPORT",
+		ContentType: tracking.JSONContent,
+		Action:      tracking.ActionRequest,
+		Message: &tracking.Message{
+			Endpoint: "",
+			Body:     string(d),
+		},
+	})
+
+	return report, nil
 }
 
-func (s *service) mapBssOfferToOffer(bssOffer model.BssOffer) (*model.Offer, error) {
-	offer := model.Offer{
-		ExternalID:      &bssOffer.ID,
-		Name:            bssOffer.Name,
-		ClientType:      model.IndividualClienType,
-		Paymentmode:     model.PostpaidPayMode,
-		Fare:            bssOffer.MontlyFee,
-		ActivationFare:  bssOffer.OneOfFee,
-		Supplementaries: []string{},
+func (s *service) reconcile(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest, dryRun bool,
+) (*model.ReconcileReport, error) {
+	report := model.ReconcileReport{
+		DryRun:   dryRun,
+		Scopes:   make([]model.ReconcileScope, 0),
+		Removals: make([]model.OfferSyncResult, 0),
+		Invalid:  make([]model.OfferSyncResult, 0),
 	}
 
-	if bssOffer.PayMode == model.AllBssPaymode {
-		offer.Paymentmode = model.AllPayMode
-	}
+	scopes := make(map[model.ReconcileScope]bool)
+	primaries := make(map[string]bool)
+	supplementaries := make(map[string]bool)
 
-	if bssOffer.PayMode == model.PostpaidBssPaymode {
-		offer.Paymentmode = model.PostpaidPayMode
+	valid := model.BssSyncOfferRequest{
+		SyncOffers: make([]model.BSSOfferRequest, 0, len(bssSyncOffer.SyncOffers)),
 	}
 
-	if bssOffer.Type == model.CorporativeBssOfferClient {
-		offer.ClientType = model.CorporativeClienType
-	}
+	for i := range bssSyncOffer.SyncOffers {
+		bssOffer := bssSyncOffer.SyncOffers[i].Offer
 
-	if bssOffer.Attributes != nil && len((*bssOffer.Attributes).Attribute) > 0 {
-		for _, attributte := range (*bssOffer.Attributes).Attribute {
-			switch attributte.Code {
-			case "C_PH2_SERVICE_TYPE":
-				if _, ok := s.confCategories[attributte.Value]; !ok {
-					return nil, errors.New("service type cannot be founded")
-				}
+		present := supplementaries
+		if bssOffer.PrimaryFlag == "1" {
+			present = primaries
+		}
 
-				offer.Category = s.confCategories[attributte.Value].Category
-				offer.Type = s.confCategories[attributte.Value].Type
+		// offers in the payload are never removed by the reconcile, suspended and retired ones are removed by the
+		// sync and invalid ones are kept as stored
+		present[bssOffer.ID] = true
 
-			case "CN_ALIAS_NUM":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+		nOffer, err := s.mapBssOfferToOffer(bssOffer)
+		if err == nil {
+			err = s.checkStrictMapping(nOffer)
+		}
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+		if err != nil {
+			report.Invalid = append(report.Invalid, model.OfferSyncResult{
+				ExternalID: bssOffer.ID,
+				Name:       bssOffer.Name,
+				Primary:    bssOffer.PrimaryFlag == "1",
+				Action:     model.InvalidSyncAction,
+				Error:      err.Error(),
+			})
 
-				d, _ := strconv.Atoi(attributte.Value)
+			continue
+		}
 
-				offer.DataCenterResourceAttributtes.AliasQty = &d
-			case "C_BD_NUM":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+		valid.SyncOffers = append(valid.SyncOffers, bssSyncOffer.SyncOffers[i])
 
-				offer.DataCenterResourceAttributtes.Database = s.checkDatabaseNil(offer.DataCenterResourceAttributtes.Database)
+		scope := model.ReconcileScope{Category: nOffer.Category, Type: nOffer.Type}
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+		if scope.Category != "" && !scopes[scope] {
+			scopes[scope] = true
 
-				d, _ := strconv.Atoi(attributte.Value)
+			report.Scopes = append(report.Scopes, scope)
+		}
+	}
 
-				offer.DataCenterResourceAttributtes.Database.Quantity = d
-			case "CN_BD_SPACE":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+	primaryRemovals, primaryInScope, err := s.reconcileRemovals(ctx, s.repository, scopes, primaries, true)
+	if err != nil {
+		return nil, err
+	}
 
-				offer.DataCenterResourceAttributtes.Database = s.checkDatabaseNil(offer.DataCenterResourceAttributtes.Database)
+	supplementaryRemovals, supplementaryInScope, err := s.reconcileRemovals(ctx, s.supplementaryRepository, scopes,
+		supplementaries, false)
+	if err != nil {
+		return nil, err
+	}
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+	report.InScope = primaryInScope + supplementaryInScope
+	report.Removals = append(append(report.Removals, primaryRemovals...), supplementaryRemovals...)
 
-				d, _ := strconv.ParseFloat(attributte.Value, 64)
+	report.ThresholdExceeded = s.settings.MaxRemovalRatio > 0 && report.InScope > 0 &&
+		float64(len(report.Removals))/float64(report.InScope) > s.settings.MaxRemovalRatio
 
-				offer.DataCenterResourceAttributtes.Database.Amount = d
-			case "C_BD_SPACE_UNIT":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+	if dryRun {
+		return &report, nil
+	}
 
-				offer.DataCenterResourceAttributtes.Database = s.checkDatabaseNil(offer.DataCenterResourceAttributtes.Database)
+	if report.ThresholdExceeded {
+		return nil, fmt.Errorf("%w, %d of %d offers in scope would be removed", ErrReconcileThreshold,
+			len(report.Removals), report.InScope)
+	}
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+	syncReport, err := s.sync(ctx, appID, valid)
+	if err != nil {
+		return nil, err
+	}
 
-				offer.DataCenterResourceAttributtes.Database.Unit = attributte.Value
+	report.Sync = syncReport
 
-				if offer.DataCenterResourceAttributtes.Database.Unit == "" {
-					offer.DataCenterResourceAttributtes.Database.Unit = "MB"
-				}
+	for i := range report.Removals {
+		repository := s.supplementaryRepository
+		if report.Removals[i].Primary {
+			repository = s.repository
+		}
 
-			case "CN_CPU_NUM":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+		if err = repository.RemoveByExternalID(ctx, report.Removals[i].ExternalID); err != nil {
+			return nil, err
+		}
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+		report.Removals[i].Written = true
+	}
 
-				d, _ := strconv.Atoi(attributte.Value)
+	return &report, nil
+}
 
-				offer.DataCenterResourceAttributtes.CPUQty = &d
-			case "CN_FTP_NUM":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+// reconcileRemovals lists the stored offers inside the reconciled scopes that are absent from the payload
+func (s *service) reconcileRemovals(ctx context.Context, repository repository.OfferRepository,
+	scopes map[model.ReconcileScope]bool, present map[string]bool, primary bool,
+) ([]model.OfferSyncResult, int, error) {
+	offers, err := repository.All(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+	removals := make([]model.OfferSyncResult, 0)
+	inScope := 0
 
-				d, _ := strconv.Atoi(attributte.Value)
+	for i := range offers {
+		if offers[i].ExternalID == nil || !scopes[model.ReconcileScope{Category: offers[i].Category, Type: offers[i].Type}] {
+			continue
+		}
 
-				offer.DataCenterResourceAttributtes.FTPQty = &d
-			case "CN_PORT_NUM":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+		inScope++
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+		if present[*offers[i].ExternalID] {
+			continue
+		}
 
-				d, _ := strconv.Atoi(attributte.Value)
+		removals = append(removals, model.OfferSyncResult{
+			ExternalID: *offers[i].ExternalID,
+			Name:       offers[i].Name,
+			Primary:    primary,
+			Action:     model.RemoveSyncAction,
+		})
+	}
 
-				offer.DataCenterResourceAttributtes.NetworkInterfaceQty = &d
-			case "CN_IP_NUM":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+	return removals, inScope, nil
+}
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+func (s *service) Validate(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest) (*model.SyncValidation, error) {
+	if err := s.loadServiceTypes(ctx); err != nil {
+		msg := fmt.Sprintf("[%s] loading service types error [%s]", appID, err)
 
-				offer.DataCenterResourceAttributtes.PublicIPAddress = &attributte.Value
-			case "CN_RAM_SPACE":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+		s.logger.Error(msg)
 
-				offer.DataCenterResourceAttributtes.RAM = s.checkRAMNil(offer.DataCenterResourceAttributtes.RAM)
+		return nil, err
+	}
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+	validation := model.SyncValidation{
+		Valid:  true,
+		Offers: make([]model.OfferValidation, 0, len(bssSyncOffer.SyncOffers)),
+	}
+
+	primaries := make(map[string]bool)
+
+	for i := range bssSyncOffer.SyncOffers {
+		if bssSyncOffer.SyncOffers[i].Offer.PrimaryFlag == "1" {
+			primaries[bssSyncOffer.SyncOffers[i].Offer.ID] = true
+		}
+	}
+
+	for i := range bssSyncOffer.SyncOffers {
+		offerValidation, err := s.validateOffer(ctx, bssSyncOffer.SyncOffers[i].Offer, primaries)
+		if err != nil {
+			msg := fmt.Sprintf("[%s] validating offer [%s] error [%s]", appID, bssSyncOffer.SyncOffers[i].Offer.ID, err)
+
+			s.logger.Error(msg)
+
+			return nil, err
+		}
+
+		if offerValidation.Action == model.InvalidSyncAction {
+			validation.Valid = false
+		}
+
+		validation.Offers = append(validation.Offers, *offerValidation)
+	}
+
+	return &validation, nil
+}
+
+func (s *service) validateOffer(ctx context.Context, bssOffer model.BssOffer, primaries map[string]bool,
+) (*model.OfferValidation, error) {
+	validation := model.OfferValidation{
+		ExternalID: bssOffer.ID,
+		Name:       bssOffer.Name,
+		Primary:    bssOffer.PrimaryFlag == "1",
+	}
+
+	repository := s.supplementaryRepository
+	if validation.Primary {
+		repository = s.repository
+	}
+
+	offer, err := repository.GetByExternalID(ctx, bssOffer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if bssOffer.Status == model.SuspendBssStatus || bssOffer.Status == model.RetirementBssStatus {
+		validation.Action = model.UnchangedSyncAction
+
+		if offer != nil {
+			validation.Action = model.RemoveSyncAction
+			validation.Offer = offer
+		}
+
+		return &validation, nil
+	}
+
+	nOffer, err := s.mapBssOfferToOffer(bssOffer)
+	if err != nil {
+		validation.Action = model.InvalidSyncAction
+		validation.Errors = append(validation.Errors, err.Error())
+
+		return &validation, nil
+	}
+
+	validation.Offer = nOffer
+	validation.Diagnostics = nOffer.Diagnostics
+
+	if err = s.checkStrictMapping(nOffer); err != nil {
+		validation.Errors = append(validation.Errors, err.Error())
+	}
+
+	// supplementaries such as VPN and DNS offers follow the rules of their type too
+	validation.Violations = checkOfferType(*nOffer)
+
+	for _, violation := range validation.Violations {
+		validation.Errors = append(validation.Errors, violation.Message)
+	}
+
+	if validation.Primary {
+		if err = s.linkSupplementaries(ctx, nOffer, true); err != nil {
+			return nil, err
+		}
+
+		missing, err := s.linkComponents(ctx, nOffer)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, externalID := range missing {
+			if !primaries[externalID] {
+				validation.Errors = append(validation.Errors, fmt.Sprintf("bundle component [%s] not found", externalID))
+			}
+		}
+	}
+
+	if offer != nil {
+		nOffer.ID = offer.ID
+		nOffer.CreatedAt = offer.CreatedAt
+		nOffer.UpdatedAt = offer.UpdatedAt
+		nOffer.Version = offer.Version
+	}
+
+	switch {
+	case len(validation.Errors) > 0:
+		validation.Action = model.InvalidSyncAction
+	case offer == nil:
+		validation.Action = model.CreateSyncAction
+	default:
+		validation.Changes = diffOffers(*offer, *nOffer)
+
+		validation.Action = model.UpdateSyncAction
+
+		if len(validation.Changes) == 0 {
+			validation.Action = model.UnchangedSyncAction
+		}
+	}
+
+	return &validation, nil
+}
+
+// syncOffer syncs the offer again from its stored state when another write modified it meanwhile
+func (s *service) syncOffer(ctx context.Context, bssOffer model.BssOffer) (*model.OfferSyncResult, error) {
+	for attempt := 0; ; attempt++ {
+		var (
+			result *model.OfferSyncResult
+			err    error
+		)
+
+		if bssOffer.PrimaryFlag == "1" {
+			result, err = s.syncPrimaryOffer(ctx, bssOffer)
+		} else {
+			result, err = s.syncSupplementaryOffer(ctx, bssOffer)
+		}
+
+		if !errors.Is(err, ErrVersionConflict) || attempt == syncRetries {
+			return result, err
+		}
+	}
+}
+
+func (s *service) syncPrimaryOffer(ctx context.Context, bssOffer model.BssOffer) (*model.OfferSyncResult, error) {
+	result := model.OfferSyncResult{
+		ExternalID: bssOffer.ID,
+		Name:       bssOffer.Name,
+		Primary:    true,
+	}
+
+	if bssOffer.Status == model.SuspendBssStatus || bssOffer.Status == model.RetirementBssStatus {
+		if err := s.removeSyncedOffer(ctx, s.repository, bssOffer, &result); err != nil {
+			return nil, err
+		}
+
+		return &result, nil
+	}
+
+	offer, err := s.repository.GetByExternalID(ctx, bssOffer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	nOffer, err := s.mapBssOfferToOffer(bssOffer)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.checkStrictMapping(nOffer); err != nil {
+		return nil, err
+	}
+
+	if result.Violations = checkOfferType(*nOffer); len(result.Violations) > 0 {
+		result.Action = model.InvalidSyncAction
+
+		if err = s.flagTypeViolations(ctx, s.repository, offer, &result); err != nil {
+			return nil, err
+		}
+
+		return &result, nil
+	}
+
+	if err = s.linkSupplementaries(ctx, nOffer, false); err != nil {
+		return nil, err
+	}
+
+	missing, err := s.linkComponents(ctx, nOffer)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("bundle [%s] components %v not found", bssOffer.ID, missing)
+	}
+
+	if err = s.writeSyncedOffer(ctx, s.repository, offer, nOffer, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (s *service) syncSupplementaryOffer(ctx context.Context, bssOffer model.BssOffer) (*model.OfferSyncResult, error) {
+	result := model.OfferSyncResult{
+		ExternalID: bssOffer.ID,
+		Name:       bssOffer.Name,
+	}
+
+	if bssOffer.Status == model.SuspendBssStatus || bssOffer.Status == model.RetirementBssStatus {
+		if err := s.removeSyncedOffer(ctx, s.supplementaryRepository, bssOffer, &result); err != nil {
+			return nil, err
+		}
+
+		return &result, nil
+	}
+
+	offer, err := s.supplementaryRepository.GetByExternalID(ctx, bssOffer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	nOffer, err := s.mapBssOfferToOffer(bssOffer)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.checkStrictMapping(nOffer); err != nil {
+		return nil, err
+	}
+
+	// supplementaries such as VPN and DNS offers follow the rules of their type as primaries do
+	if result.Violations = checkOfferType(*nOffer); len(result.Violations) > 0 {
+		result.Action = model.InvalidSyncAction
+
+		if err = s.flagTypeViolations(ctx, s.supplementaryRepository, offer, &result); err != nil {
+			return nil, err
+		}
+
+		return &result, nil
+	}
+
+	if err = s.writeSyncedOffer(ctx, s.supplementaryRepository, offer, nOffer, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// linkSupplementaries resolves the mapped references to supplementary documents, the unknown ones are stored as
+// pending placeholders until commercial system syncs them. On a dry run nothing is stored and the references to
+// unknown supplementaries are kept with an empty id, as the id of the placeholder is only known once it is created
+func (s *service) linkSupplementaries(ctx context.Context, nOffer *model.Offer, dryRun bool) error {
+	if len(nOffer.References) == 0 {
+		return nil
+	}
+
+	references := nOffer.References
+
+	nOffer.Supplementaries = make([]string, 0, len(references))
+	nOffer.References = make([]model.SupplementaryReference, 0, len(references))
+
+	for i := range references {
+		externalID := references[i].ExternalID
+
+		supOffer, err := s.supplementaryRepository.GetByExternalID(ctx, externalID)
+		if err != nil {
+			return err
+		}
+
+		if supOffer == nil && dryRun {
+			references[i].ID = ""
+
+			nOffer.Supplementaries = append(nOffer.Supplementaries, "")
+			nOffer.References = append(nOffer.References, references[i])
+
+			continue
+		}
+
+		if supOffer == nil {
+			supOffer, err = s.supplementaryRepository.CreatePending(ctx, externalID)
+			if err != nil {
+				return err
+			}
+		}
+
+		references[i].ID = supOffer.ID
+
+		nOffer.Supplementaries = append(nOffer.Supplementaries, supOffer.ID)
+		nOffer.References = append(nOffer.References, references[i])
+	}
+
+	return nil
+}
+
+// linkComponents resolves the bundle components to stored primary offers and returns the external ids not found
+func (s *service) linkComponents(ctx context.Context, nOffer *model.Offer) ([]string, error) {
+	missing := make([]string, 0)
+
+	for i := range nOffer.Components {
+		component, err := s.repository.GetByExternalID(ctx, nOffer.Components[i].ExternalID)
+		if err != nil {
+			return nil, err
+		}
+
+		if component == nil {
+			missing = append(missing, nOffer.Components[i].ExternalID)
+			continue
+		}
+
+		nOffer.Components[i].ID = component.ID
+	}
+
+	return missing, nil
+}
+
+func (s *service) writeSyncedOffer(ctx context.Context, repository repository.OfferRepository, offer *model.Offer,
+	nOffer *model.Offer, result *model.OfferSyncResult,
+) error {
+	result.Action = model.CreateSyncAction
+
+	if offer != nil {
+		nOffer.ID = offer.ID
+		nOffer.CreatedAt = offer.CreatedAt
+		nOffer.UpdatedAt = offer.UpdatedAt
+		nOffer.Version = offer.Version
+
+		result.Changes = diffOffers(*offer, *nOffer)
+
+		if len(result.Changes) == 0 {
+			result.Action = model.UnchangedSyncAction
+
+			// diagnostics are not offer data, new ones are stored without counting as a change
+			if !diagnosticsDiffer(offer.Diagnostics, nOffer.Diagnostics) {
+				return nil
+			}
+		} else {
+			result.Action = model.UpdateSyncAction
+		}
+	}
+
+	if _, err := repository.UpsertByExternalID(ctx, *nOffer); err != nil {
+		return err
+	}
+
+	result.Written = true
+
+	return nil
+}
+
+// flagTypeViolations stores the violations of the result as diagnostics of the stored offer, it keeps its previous
+// version so the diagnostics tell it is out of date until a valid version is synced and replaces them
+func (s *service) flagTypeViolations(ctx context.Context, repository repository.OfferRepository, offer *model.Offer,
+	result *model.OfferSyncResult,
+) error {
+	if offer == nil {
+		return nil
+	}
+
+	diagnostics := make([]model.Diagnostic, 0, len(offer.Diagnostics)+len(result.Violations))
+
+	for _, diagnostic := range offer.Diagnostics {
+		if diagnostic.Type != model.TypeViolationDiagnostic {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+
+	for _, violation := range result.Violations {
+		diagnostics = append(diagnostics, model.Diagnostic{
+			Type:    model.TypeViolationDiagnostic,
+			Code:    violation.Attribute,
+			Value:   string(violation.Rule),
+			Message: fmt.Sprintf("%s, the synced version was not stored", violation.Message),
+		})
+	}
+
+	if !diagnosticsDiffer(offer.Diagnostics, diagnostics) {
+		return nil
+	}
+
+	flagged := *offer
+	flagged.Diagnostics = diagnostics
+
+	if _, err := repository.UpsertByExternalID(ctx, flagged); err != nil {
+		return err
+	}
+
+	result.Written = true
+
+	return nil
+}
+
+func (s *service) removeSyncedOffer(ctx context.Context, repository repository.OfferRepository, bssOffer model.BssOffer,
+	result *model.OfferSyncResult,
+) error {
+	result.Action = model.UnchangedSyncAction
+
+	offer, err := repository.GetByExternalID(ctx, bssOffer.ID)
+	if err != nil {
+		return err
+	}
+
+	if offer == nil {
+		return nil
+	}
+
+	result.Action = model.RemoveSyncAction
+
+	if err = repository.RemoveByExternalID(ctx, bssOffer.ID); err != nil {
+		return err
+	}
+
+	result.Written = true
+
+	return nil
+}
+
+func (s *service) mapBssOfferToOffer(bssOffer model.BssOffer) (*model.Offer, error) {
+	offer := model.Offer{
+		ExternalID:      &bssOffer.ID,
+		Name:            bssOffer.Name,
+		ClientType:      model.IndividualClienType,
+		Paymentmode:     model.PostpaidPayMode,
+		ActivationFare:  bssOffer.OneOfFee,
+		Supplementaries: []string{},
+	}
+
+	if bssOffer.PayMode == model.AllBssPaymode {
+		offer.Paymentmode = model.AllPayMode
+	}
+
+	if bssOffer.PayMode == model.PostpaidBssPaymode {
+		offer.Paymentmode = model.PostpaidPayMode
+	}
+
+	if bssOffer.Type == model.CorporativeBssOfferClient {
+		offer.ClientType = model.CorporativeClienType
+	}
+
+	// fee attributes are kept as sent so prices do not go through float64
+	fareAmount := ""
+	usageAmount := ""
+	// attribute code each resource unit was read from, unit diagnostics point to it
+	unitCodes := make(map[string]string)
+
+	if bssOffer.Attributes != nil && len((*bssOffer.Attributes).Attribute) > 0 {
+		for _, attributte := range (*bssOffer.Attributes).Attribute {
+			switch attributte.Code {
+			case "C_PH2_SERVICE_TYPE":
+				serviceType, ok := s.serviceType(attributte.Value)
+				if !ok {
+					return nil, errors.New("service type cannot be founded")
+				}
+
+				offer.Category = serviceType.Category
+				offer.Type = serviceType.Type
+
+			case "CN_ALIAS_NUM":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				d := s.parseInt(&offer, attributte)
+
+				offer.DataCenterResourceAttributtes.AliasQty = &d
+			case "C_BD_NUM":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				offer.DataCenterResourceAttributtes.Database = s.checkDatabaseNil(offer.DataCenterResourceAttributtes.Database)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				d := s.parseInt(&offer, attributte)
+
+				offer.DataCenterResourceAttributtes.Database.Quantity = d
+			case "CN_BD_SPACE":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				offer.DataCenterResourceAttributtes.Database = s.checkDatabaseNil(offer.DataCenterResourceAttributtes.Database)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				d := s.parseFloat(&offer, attributte)
+
+				offer.DataCenterResourceAttributtes.Database.Amount = d
+			case "C_BD_SPACE_UNIT":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				offer.DataCenterResourceAttributtes.Database = s.checkDatabaseNil(offer.DataCenterResourceAttributtes.Database)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				offer.DataCenterResourceAttributtes.Database.Unit = s.checkUnit(&offer, offer.DataCenterResourceAttributtes.Database.Unit, attributte)
+				unitCodes["database"] = attributte.Code
+
+			case "CN_CPU_NUM":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				d := s.parseInt(&offer, attributte)
+
+				offer.DataCenterResourceAttributtes.CPUQty = &d
+			case "CN_FTP_NUM":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				d := s.parseInt(&offer, attributte)
+
+				offer.DataCenterResourceAttributtes.FTPQty = &d
+			case "CN_PORT_NUM":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				d := s.parseInt(&offer, attributte)
+
+				offer.DataCenterResourceAttributtes.NetworkInterfaceQty = &d
+			case "CN_IP_NUM":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				offer.DataCenterResourceAttributtes.PublicIPAddress = &attributte.Value
+			case "CN_RAM_SPACE":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				offer.DataCenterResourceAttributtes.RAM = s.checkRAMNil(offer.DataCenterResourceAttributtes.RAM)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				d := s.parseFloat(&offer, attributte)
+
+				offer.DataCenterResourceAttributtes.RAM.Amount = d
+			case "C_RAM_SPACE_UNIT":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				offer.DataCenterResourceAttributtes.RAM = s.checkRAMNil(offer.DataCenterResourceAttributtes.RAM)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				offer.DataCenterResourceAttributtes.RAM.Unit = s.checkUnit(&offer, offer.DataCenterResourceAttributtes.RAM.Unit, attributte)
+				unitCodes["ram"] = attributte.Code
+
+			case "C_DISK_SPACE":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				offer.DataCenterResourceAttributtes.HDD = s.checkHDDNil(offer.DataCenterResourceAttributtes.HDD)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				d := s.parseFloat(&offer, attributte)
+
+				offer.DataCenterResourceAttributtes.HDD.Amount = d
+			case "C_DISK_SPACE_UNIT":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				offer.DataCenterResourceAttributtes.HDD = s.checkHDDNil(offer.DataCenterResourceAttributtes.HDD)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				offer.DataCenterResourceAttributtes.HDD.Unit = s.checkUnit(&offer, offer.DataCenterResourceAttributtes.HDD.Unit, attributte)
+				unitCodes["hdd"] = attributte.Code
+
+			case "C_RATE_NUM":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				isVPN := s.checkifAccessTypeisVPN((*bssOffer.Attributes).Attribute)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				d := s.parseFloat(&offer, attributte)
+
+				if isVPN {
+					offer.DataCenterResourceAttributtes.VPN = s.checkVPNNil(offer.DataCenterResourceAttributtes.VPN)
+
+					offer.DataCenterResourceAttributtes.VPN.Speed = d
+
+					break
+				}
+
+				offer.DataCenterResourceAttributtes.Bandwidth = s.checkBandwithNil(offer.DataCenterResourceAttributtes.Bandwidth)
+
+				offer.DataCenterResourceAttributtes.Bandwidth.Amount = d
+
+			case "C_RATE_UNIT":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				isVPN := s.checkifAccessTypeisVPN((*bssOffer.Attributes).Attribute)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				if isVPN {
+					offer.DataCenterResourceAttributtes.VPN = s.checkVPNNil(offer.DataCenterResourceAttributtes.VPN)
+
+					offer.DataCenterResourceAttributtes.VPN.Unit = s.checkUnit(&offer, offer.DataCenterResourceAttributtes.VPN.Unit, attributte)
+					unitCodes["vpn"] = attributte.Code
+
+					break
+				}
+
+				offer.DataCenterResourceAttributtes.Bandwidth = s.checkBandwithNil(offer.DataCenterResourceAttributtes.Bandwidth)
+
+				offer.DataCenterResourceAttributtes.Bandwidth.Unit = s.checkUnit(&offer, offer.DataCenterResourceAttributtes.Bandwidth.Unit, attributte)
+				unitCodes["bandwidth"] = attributte.Code
+
+			case "CN_VPN_LANIP":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				offer.DataCenterResourceAttributtes.VPN = s.checkVPNNil(offer.DataCenterResourceAttributtes.VPN)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				offer.DataCenterResourceAttributtes.VPN.IPAddress = attributte.Value
+
+			case "CN_VPN_NAME":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				offer.DataCenterResourceAttributtes.VPN = s.checkVPNNil(offer.DataCenterResourceAttributtes.VPN)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				offer.DataCenterResourceAttributtes.VPN.Name = attributte.Value
+
+			case "CN_DNS":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				offer.DataCenterResourceAttributtes.DNS = s.checkDNSNil(offer.DataCenterResourceAttributtes.DNS)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				offer.DataCenterResourceAttributtes.DNS.DNS = attributte.Value
+
+			case "CN_DNS_CNAME":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				offer.DataCenterResourceAttributtes.DNS = s.checkDNSNil(offer.DataCenterResourceAttributtes.DNS)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				offer.DataCenterResourceAttributtes.DNS.Name = attributte.Value
+
+			case "C_ACCESS_TYPE":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				offer.DataCenterResourceAttributtes.Bandwidth = s.checkBandwithNil(offer.DataCenterResourceAttributtes.Bandwidth)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				access := model.NationalAccess
+
+				if attributte.Value == "1" {
+					access = model.InternationalAccess
+				}
+
+				offer.DataCenterResourceAttributtes.Bandwidth.Type = access
+
+			case "CN_VPS_LANIP":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				offer.DataCenterResourceAttributtes.LANIPAddress = &attributte.Value
+
+			case "CN_VPS_WANIP":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				offer.DataCenterResourceAttributtes.WANIPAddress = &attributte.Value
+
+			case "C_SAVEVM_FALG":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				value := s.parseFlag(&offer, attributte)
+
+				if attributte.Type != "1" {
+					offer.DataCenterResourceAttributtes.Included = false
+				}
+
+				offer.DataCenterResourceAttributtes.SaveVM = &value
+
+			case "C_TEMPPREPAID_FLAG":
+				offer.Temporal = s.parseFlag(&offer, attributte)
+
+			case "amount":
+				if attributte.Value == "" {
+					continue
+				}
+
+				if _, err := model.ParseMoney(attributte.Value, ""); err != nil {
+					s.addInvalidValueDiagnostic(&offer, attributte, "value is not a valid amount")
+					continue
+				}
+
+				fareAmount = attributte.Value
+
+			case s.settings.UsageAttribute:
+				if s.settings.UsageAttribute == "" || attributte.Value == "" {
+					continue
+				}
+
+				if _, err := model.ParseMoney(attributte.Value, ""); err != nil {
+					s.addInvalidValueDiagnostic(&offer, attributte, "value is not a valid amount")
+					continue
+				}
+
+				usageAmount = attributte.Value
+
+			case "measureId":
+				currency, ok := s.settings.Currencies[attributte.Value]
+				if !ok {
+					currency = s.settings.DefaultCurrency
+
+					offer.Diagnostics = append(offer.Diagnostics, model.Diagnostic{
+						Type:    model.UnknownCurrencyDiagnostic,
+						Code:    attributte.Code,
+						Value:   attributte.Value,
+						Message: fmt.Sprintf("measure id has no currency configured, mapped as %s", currency),
+					})
+				}
+
+				offer.Currency = &currency
+
+			case "C_PROTOCOLO_PUERTO":
+				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+
+				offer.DataCenterResourceAttributtes.Port = s.checkPortNil(offer.DataCenterResourceAttributtes.Port)
+
+				offer.DataCenterResourceAttributtes.Port.Description = attributte.Value
+
+			case "C_YP_LISTING_TIER":
+				offer.YellowPagesAttributes = s.checkYellowPagesAttributesNil(offer.YellowPagesAttributes)
+
+				offer.YellowPagesAttributes.ListingTier = strings.ToUpper(strings.TrimSpace(attributte.Value))
+
+			case "C_YP_AD_SIZE":
+				offer.YellowPagesAttributes = s.checkYellowPagesAttributesNil(offer.YellowPagesAttributes)
+
+				offer.YellowPagesAttributes.AdSize = strings.ToUpper(strings.TrimSpace(attributte.Value))
+
+			case "CN_YP_DURATION":
+				offer.YellowPagesAttributes = s.checkYellowPagesAttributesNil(offer.YellowPagesAttributes)
+
+				d := s.parseInt(&offer, attributte)
+
+				offer.YellowPagesAttributes.Duration = &d
+
+			case "C_YP_DURATION_UNIT":
+				offer.YellowPagesAttributes = s.checkYellowPagesAttributesNil(offer.YellowPagesAttributes)
+
+				offer.YellowPagesAttributes.DurationUnit = strings.ToUpper(strings.TrimSpace(attributte.Value))
+				unitCodes["duration"] = attributte.Code
+
+			case "C_YP_REGIONS":
+				offer.YellowPagesAttributes = s.checkYellowPagesAttributesNil(offer.YellowPagesAttributes)
+
+				for _, region := range strings.Split(attributte.Value, ",") {
+					if region = strings.ToUpper(strings.TrimSpace(region)); region != "" {
+						offer.YellowPagesAttributes.Regions = append(offer.YellowPagesAttributes.Regions, region)
+					}
+				}
+
+			case "C_YP_LOGO_FLAG":
+				offer.YellowPagesAttributes = s.checkYellowPagesAttributesNil(offer.YellowPagesAttributes)
+
+				value := s.parseFlag(&offer, attributte)
+
+				offer.YellowPagesAttributes.Logo = &value
+
+			case "C_YP_WEBLINK_FLAG":
+				offer.YellowPagesAttributes = s.checkYellowPagesAttributesNil(offer.YellowPagesAttributes)
+
+				value := s.parseFlag(&offer, attributte)
+
+				offer.YellowPagesAttributes.WebLink = &value
+
+			case "C_DATAC_ACCESS_TYPE":
+				// read by checkifAccessTypeisVPN when mapping rate attributes
+
+			default:
+				if s.isBillingAttribute(attributte.Code) {
+					// read by mapBilling
+					continue
+				}
+
+				offer.Diagnostics = append(offer.Diagnostics, model.Diagnostic{
+					Type:    model.UnknownAttributeDiagnostic,
+					Code:    attributte.Code,
+					Value:   attributte.Value,
+					Message: "attribute code is not recognized",
+				})
+			}
+		}
+	}
+
+	currency := s.settings.DefaultCurrency
+
+	if offer.Currency != nil {
+		currency = *offer.Currency
+	}
+
+	s.normalizeUnits(&offer, unitCodes)
+
+	if attributes := offer.YellowPagesAttributes; attributes != nil && attributes.Duration != nil {
+		attributes.DurationUnit, attributes.Months = s.normalizeDuration(&offer, unitCodes["duration"],
+			*attributes.Duration, attributes.DurationUnit)
+	}
+
+	offer.Fees = s.mapFees(&offer, bssOffer, fareAmount, usageAmount, currency)
+
+	offer.Price = &model.OfferPrice{
+		Fare:           feeAmount(offer.Fees, model.MonthlyFee, currency),
+		ActivationFare: feeAmount(offer.Fees, model.OneOffFee, currency),
+	}
+
+	offer.Fare, _ = offer.Price.Fare.Rat().Float64()
+
+	if bssOffer.Attributes != nil {
+		s.mapBilling(&offer, bssOffer.Attributes.Attribute, currency)
+	}
+
+	offer.Bundle = bssOffer.BundleFlag == "1"
+
+	for _, catalog := range bssOffer.Catalogs.Catalogs {
+		offer.Catalogs = append(offer.Catalogs, catalog.ID)
+	}
+
+	if bssOffer.PrimaryFlag == "1" && bssOffer.Relationships != nil {
+		for _, attached := range bssOffer.Relationships.Attached {
+			relationType := s.mapRelationType(&offer, attached)
+
+			// bundled relationships of a bundle point to the primary offers it is composed of
+			if offer.Bundle && relationType == model.BundledRelationType {
+				offer.Components = append(offer.Components, model.BundleComponent{
+					ExternalID: attached.ID,
+				})
+
+				continue
+			}
+
+			offer.References = append(offer.References, model.SupplementaryReference{
+				ExternalID: attached.ID,
+				Type:       relationType,
+			})
+		}
+	}
+
+	if bssOffer.EffectiveDate != nil {
+		offer.EffectiveDate = *bssOffer.EffectiveDate
+		offer.EffectiveAt = s.offerDate(&offer, "eff_date", offer.EffectiveDate)
+	}
+
+	if bssOffer.ExpirationDate != nil {
+		offer.ExpirationDate = *bssOffer.ExpirationDate
+		offer.ExpirationAt = s.offerDate(&offer, "exp_date", offer.ExpirationDate)
+	}
+
+	return &offer, nil
+}
+
+// offerDate returns the unix time of the date, a date that can not be read leaves the offer unbounded on that side
+// and is reported under the code
+func (s *service) offerDate(offer *model.Offer, code string, value string) int64 {
+	if value == "" {
+		return 0
+	}
+
+	date, ok := model.ParseOfferDate(value)
+	if !ok {
+		s.addInvalidValueDiagnostic(offer, model.BssAttribute{Code: code, Value: value}, "value is not a valid date")
+	}
+
+	return date
+}
+
+// normalizeUnits sets the canonical unit and base amount of every quantity, quantities in an unknown unit are kept as
+// sent without base amount and reported under the attribute code in unitCodes their unit was read from
+func (s *service) normalizeUnits(offer *model.Offer, unitCodes map[string]string) {
+	attributes := offer.DataCenterResourceAttributtes
+	if attributes == nil {
+		return
+	}
+
+	if attributes.RAM != nil {
+		attributes.RAM.Unit, attributes.RAM.Bytes = s.normalizeStorage(offer, unitCodes["ram"], attributes.RAM.Amount,
+			attributes.RAM.Unit)
+	}
+
+	if attributes.HDD != nil {
+		attributes.HDD.Unit, attributes.HDD.Bytes = s.normalizeStorage(offer, unitCodes["hdd"], attributes.HDD.Amount,
+			attributes.HDD.Unit)
+	}
+
+	if attributes.Database != nil {
+		attributes.Database.Unit, attributes.Database.Bytes = s.normalizeStorage(offer, unitCodes["database"],
+			attributes.Database.Amount, attributes.Database.Unit)
+	}
+
+	if attributes.Bandwidth != nil {
+		attributes.Bandwidth.Unit, attributes.Bandwidth.BitsPerSecond = s.normalizeBandwidth(offer,
+			unitCodes["bandwidth"], attributes.Bandwidth.Amount, attributes.Bandwidth.Unit)
+	}
+
+	if attributes.VPN != nil {
+		attributes.VPN.Unit, attributes.VPN.BitsPerSecond = s.normalizeBandwidth(offer, unitCodes["vpn"],
+			attributes.VPN.Speed, attributes.VPN.Unit)
+	}
+}
+
+func (s *service) normalizeStorage(offer *model.Offer, code string, amount float64, unit string) (string, int64) {
+	normalized, ok := model.NormalizeStorageUnit(unit)
+	if !ok {
+		s.addUnknownUnitDiagnostic(offer, code, unit)
+
+		return unit, 0
+	}
+
+	bytes, _ := model.StorageBytes(amount, normalized)
+
+	return normalized, bytes
+}
+
+func (s *service) normalizeBandwidth(offer *model.Offer, code string, amount float64, unit string) (string, int64) {
+	normalized, ok := model.NormalizeBandwidthUnit(unit)
+	if !ok {
+		s.addUnknownUnitDiagnostic(offer, code, unit)
+
+		return unit, 0
+	}
+
+	bitsPerSecond, _ := model.BandwidthBitsPerSecond(amount, normalized)
+
+	return normalized, bitsPerSecond
+}
+
+func (s *service) normalizeDuration(offer *model.Offer, code string, amount int, unit string) (string, int) {
+	normalized, ok := model.NormalizeDurationUnit(unit)
+	if !ok {
+		s.addUnknownUnitDiagnostic(offer, code, unit)
+
+		return unit, 0
+	}
+
+	months, _ := model.DurationMonths(float64(amount), normalized)
+
+	return normalized, months
+}
+
+func (s *service) addUnknownUnitDiagnostic(offer *model.Offer, code string, unit string) {
+	offer.Diagnostics = append(offer.Diagnostics, model.Diagnostic{
+		Type:    model.UnknownUnitDiagnostic,
+		Code:    code,
+		Value:   unit,
+		Message: "unit is not recognized, the quantity can not be compared nor filtered",
+	})
+}
+
+// billedResources are the quantity attributes billing is recorded for, C_RATE_NUM is vpn_speed on vpn offers
+var billedResources = map[string]string{
+	"CN_CPU_NUM":   "cpu",
+	"CN_RAM_SPACE": "ram",
+	"C_DISK_SPACE": "hdd",
+	"C_BD_NUM":     "database_quantity",
+	"CN_BD_SPACE":  "database_space",
+	"CN_FTP_NUM":   "ftp",
+	"CN_ALIAS_NUM": "alias",
+	"CN_PORT_NUM":  "network_interfaces",
+	"CN_IP_NUM":    "public_ips",
+	"C_RATE_NUM":   "bandwidth",
+}
+
+// includedAttributeType is the attr_type of the resources included in the offer fare
+const includedAttributeType = "1"
+
+// isBillingAttribute tells if the code is configured as the max quantity or unit price attribute of a resource
+func (s *service) isBillingAttribute(code string) bool {
+	for _, billingAttribute := range s.settings.BillingAttributes {
+		if code == billingAttribute.MaxQuantity || code == billingAttribute.UnitPrice {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mapBilling records how every resource is charged from its attr_type, the attribute value is the included quantity
+// of INCLUDED resources. The max quantity and unit price are only read from the attributes configured for the
+// resource, the offered amount of an EXTRA resource is not its max quantity
+func (s *service) mapBilling(offer *model.Offer, attributes []model.BssAttribute, currency string) {
+	if offer.DataCenterResourceAttributtes == nil {
+		return
+	}
+
+	byCode := make(map[string]model.BssAttribute, len(attributes))
+
+	for _, attributte := range attributes {
+		byCode[attributte.Code] = attributte
+	}
+
+	isVPN := s.checkifAccessTypeisVPN(attributes)
+
+	for _, attributte := range attributes {
+		resource, ok := billedResources[attributte.Code]
+		if !ok {
+			continue
+		}
+
+		if attributte.Code == "C_RATE_NUM" && isVPN {
+			resource = "vpn_speed"
+		}
+
+		billing := model.ResourceBilling{Type: model.ExtraBillingType}
+
+		if attributte.Type == includedAttributeType {
+			billing.Type = model.IncludedBillingType
+
+			// invalid quantities were already reported when mapping the resource
+			billing.IncludedQuantity, _ = strconv.ParseFloat(attributte.Value, 64)
+		}
+
+		codes := s.settings.BillingAttributes[resource]
+
+		if max, ok := byCode[codes.MaxQuantity]; ok && codes.MaxQuantity != "" && max.Value != "" {
+			quantity, err := strconv.ParseFloat(max.Value, 64)
+			if err != nil || quantity < 0 {
+				s.addInvalidValueDiagnostic(offer, max, "value is not a valid quantity")
+			} else {
+				billing.MaxQuantity = &quantity
+			}
+		}
+
+		if price, ok := byCode[codes.UnitPrice]; ok && codes.UnitPrice != "" && price.Value != "" {
+			unitPrice, err := model.ParseMoney(price.Value, currency)
+			if err != nil {
+				s.addInvalidValueDiagnostic(offer, price, "value is not a valid amount")
+			} else {
+				billing.UnitPrice = &unitPrice
+			}
+		}
+
+		if offer.DataCenterResourceAttributtes.Billing == nil {
+			offer.DataCenterResourceAttributtes.Billing = make(map[string]model.ResourceBilling)
+		}
+
+		offer.DataCenterResourceAttributtes.Billing[resource] = billing
+	}
+}
+
+// mapFees applies the precedence documented on model.FeeComponent, fareAmount and usageAmount are the already
+// validated amount attribute and usage attribute configured in the settings
+func (s *service) mapFees(offer *model.Offer, bssOffer model.BssOffer, fareAmount string, usageAmount string,
+	currency string,
+) []model.FeeComponent {
+	fees := make([]model.FeeComponent, 0)
+
+	oneOff := model.MoneyFromFloat(bssOffer.OneOfFee, currency)
+	if oneOff.Cents != 0 {
+		fees = append(fees, model.FeeComponent{Type: model.OneOffFee, Amount: oneOff, Source: "oneoff_fee"})
+	}
+
+	monthly := model.FeeComponent{
+		Type:   model.MonthlyFee,
+		Amount: model.MoneyFromFloat(bssOffer.MontlyFee, currency),
+		Source: "monthly_fee",
+	}
+
+	if fareAmount != "" {
+		amount, _ := model.ParseMoney(fareAmount, currency)
+
+		if monthly.Amount.Cents == 0 {
+			monthly.Amount = amount
+			monthly.Source = "amount"
+		} else if amount.Cents != monthly.Amount.Cents {
+			offer.Diagnostics = append(offer.Diagnostics, model.Diagnostic{
+				Type:  model.ConflictingFeeDiagnostic,
+				Code:  "amount",
+				Value: fareAmount,
+				Message: fmt.Sprintf("amount attribute differs from monthly_fee %s, monthly_fee is used",
+					monthly.Amount),
+			})
+		}
+	}
+
+	if monthly.Amount.Cents != 0 {
+		fees = append(fees, monthly)
+	}
+
+	if bssOffer.TopupFee != "" {
+		topup, err := model.ParseMoney(bssOffer.TopupFee, currency)
+		if err != nil {
+			s.addInvalidValueDiagnostic(offer, model.BssAttribute{Code: "topupFee", Value: bssOffer.TopupFee},
+				"value is not a valid amount")
+		}
+
+		if err == nil && topup.Cents != 0 {
+			fees = append(fees, model.FeeComponent{Type: model.TopupFee, Amount: topup, Source: "topupFee"})
+		}
+	}
+
+	if usageAmount != "" {
+		usage, _ := model.ParseMoney(usageAmount, currency)
+
+		if usage.Cents != 0 {
+			fees = append(fees, model.FeeComponent{Type: model.UsageFee, Amount: usage, Source: s.settings.UsageAttribute})
+		}
+	}
+
+	return fees
+}
+
+func feeAmount(fees []model.FeeComponent, feeType model.FeeType, currency string) model.Money {
+	for _, fee := range fees {
+		if fee.Type == feeType {
+			return fee.Amount
+		}
+	}
+
+	return model.Money{Currency: currency}
+}
+
+func (s *service) mapRelationType(offer *model.Offer, attached model.BssAttached) model.RelationType {
+	if relationType, ok := s.settings.RelationTypes[attached.RelationType]; ok {
+		return relationType
+	}
+
+	offer.Diagnostics = append(offer.Diagnostics, model.Diagnostic{
+		Type:    model.UnknownRelationTypeDiagnostic,
+		Code:    attached.ID,
+		Value:   attached.RelationType,
+		Message: fmt.Sprintf("relation type is not recognized, mapped as %s", model.OptionalRelationType),
+	})
+
+	return model.OptionalRelationType
+}
+
+func (s *service) checkStrictMapping(offer *model.Offer) error {
+	if !s.settings.StrictMapping || len(offer.Diagnostics) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("offer [%s] rejected by strict mapping with %d diagnostics, first one [%s] [%s]",
+		*offer.ExternalID, len(offer.Diagnostics), offer.Diagnostics[0].Code, offer.Diagnostics[0].Message)
+}
+
+func (s *service) Get(ctx context.Context, id string, appID string, options model.ReadOptions) (*model.Offer, error) {
+	offer, err := s.repository.Get(ctx, id)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting offer [%s] error [%s]", appID, id, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	if offer == nil {
+		return nil, nil
+	}
+
+	offers := []model.Offer{*offer}
+
+	if err = s.applyReadOptions(ctx, offers, options); err != nil {
+		msg := fmt.Sprintf("[%s] reading offer [%s] error [%s]", appID, id, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	return &offers[0], nil
+}
+
+// SaveOffer merges the edit onto the stored primary offer, the edit must carry the stored version so edits made on
+// a stale read fail with ErrVersionConflict. Returns nil when the offer does not exist
+func (s *service) SaveOffer(ctx context.Context, appID string, request model.OfferEditRequest) (*model.Offer, error) {
+	id := request.ID
+
+	stored, err := s.repository.Get(ctx, id)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting offer [%s] error [%s]", appID, id, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	if stored == nil {
+		return nil, nil
+	}
+
+	if err = checkPriceUnchanged(*stored, request); err != nil {
+		return nil, err
+	}
+
+	offer := mergeOfferEdit(*stored, request)
+
+	if err = s.normalizeEditedUnits(&offer); err != nil {
+		return nil, err
+	}
+
+	if request.ExpirationDate != nil {
+		if offer.ExpirationAt, err = editedDate(offer.ExpirationDate); err != nil {
+			return nil, err
+		}
+	}
+
+	nOffer, err := s.repository.Upsert(ctx, offer)
+	if err != nil {
+		if !errors.Is(err, ErrVersionConflict) {
+			msg := fmt.Sprintf("[%s] saving offer [%s] error [%s]", appID, id, err)
+
+			s.logger.Error(msg)
+		}
+
+		return nil, err
+	}
+
+	return nOffer, nil
+}
+
+// editedDate returns the unix time of an edited date, an empty date removes the bound
+func editedDate(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	date, ok := model.ParseOfferDate(value)
+	if !ok {
+		return 0, fmt.Errorf("%w [%s]", ErrInvalidDate, value)
+	}
+
+	return date, nil
+}
 
-				d, _ := strconv.ParseFloat(attributte.Value, 64)
+// mergeOfferEdit sets the edited fields on the stored offer, the stored version is replaced by the edited one
+func mergeOfferEdit(offer model.Offer, request model.OfferEditRequest) model.Offer {
+	offer.Version = request.Version
 
-				offer.DataCenterResourceAttributtes.RAM.Amount = d
-			case "C_RAM_SPACE_UNIT":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+	if request.Name != nil {
+		offer.Name = *request.Name
+	}
 
-				offer.DataCenterResourceAttributtes.RAM = s.checkRAMNil(offer.DataCenterResourceAttributtes.RAM)
+	if request.ClientType != nil {
+		offer.ClientType = *request.ClientType
+	}
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+	if request.Paymentmode != nil {
+		offer.Paymentmode = *request.Paymentmode
+	}
 
-				offer.DataCenterResourceAttributtes.RAM.Unit = attributte.Value
+	if request.Temporal != nil {
+		offer.Temporal = *request.Temporal
+	}
 
-				if offer.DataCenterResourceAttributtes.RAM.Unit == "" {
-					offer.DataCenterResourceAttributtes.RAM.Unit = "MB"
-				}
+	if request.ExpirationDate != nil {
+		offer.ExpirationDate = *request.ExpirationDate
+	}
 
-			case "C_DISK_SPACE":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+	if request.Catalogs != nil {
+		offer.Catalogs = request.Catalogs
+	}
 
-				offer.DataCenterResourceAttributtes.HDD = s.checkHDDNil(offer.DataCenterResourceAttributtes.HDD)
+	if request.DataCenterResourceAttributtes != nil {
+		offer.DataCenterResourceAttributtes = request.DataCenterResourceAttributtes
+	}
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+	if request.YellowPagesAttributes != nil {
+		offer.YellowPagesAttributes = request.YellowPagesAttributes
+	}
 
-				d, _ := strconv.ParseFloat(attributte.Value, 64)
+	return offer
+}
 
-				offer.DataCenterResourceAttributtes.HDD.Amount = d
-			case "C_DISK_SPACE_UNIT":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+// normalizeEditedUnits sets the canonical values of the edited quantities, filters compare them, an edit can not
+// store a unit the mapping would report as unknown
+func (s *service) normalizeEditedUnits(offer *model.Offer) error {
+	diagnostics := offer.Diagnostics
+	offer.Diagnostics = nil
 
-				offer.DataCenterResourceAttributtes.HDD = s.checkHDDNil(offer.DataCenterResourceAttributtes.HDD)
+	s.normalizeUnits(offer, map[string]string{})
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+	if attributes := offer.YellowPagesAttributes; attributes != nil && attributes.Duration != nil {
+		attributes.DurationUnit, attributes.Months = s.normalizeDuration(offer, "", *attributes.Duration,
+			attributes.DurationUnit)
+	}
 
-				offer.DataCenterResourceAttributtes.HDD.Unit = attributte.Value
+	unknown := offer.Diagnostics
+	offer.Diagnostics = diagnostics
 
-				if offer.DataCenterResourceAttributtes.HDD.Unit == "" {
-					offer.DataCenterResourceAttributtes.HDD.Unit = "MB"
-				}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w [%s]", ErrUnknownUnit, unknown[0].Value)
+	}
 
-			case "C_RATE_NUM":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+	return nil
+}
 
-				isVPN := s.checkifAccessTypeisVPN((*bssOffer.Attributes).Attribute)
+// checkPriceUnchanged rejects edits changing the prices, they are only set by the commercial system sync
+func checkPriceUnchanged(stored model.Offer, request model.OfferEditRequest) error {
+	var storedPrice model.OfferPrice
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+	if stored.Price != nil {
+		storedPrice = *stored.Price
+	}
 
-				d, _ := strconv.ParseFloat(attributte.Value, 64)
+	storedFees := stored.Fees
+	if storedFees == nil {
+		storedFees = []model.FeeComponent{}
+	}
 
-				if isVPN {
-					offer.DataCenterResourceAttributtes.VPN = s.checkVPNNil(offer.DataCenterResourceAttributtes.VPN)
+	switch {
+	case request.Fare != nil && *request.Fare != stored.Fare:
+		return fmt.Errorf("%w [fare]", ErrPriceEdit)
+	case request.ActivationFare != nil && *request.ActivationFare != stored.ActivationFare:
+		return fmt.Errorf("%w [activation_fare]", ErrPriceEdit)
+	case request.Price != nil && *request.Price != storedPrice:
+		return fmt.Errorf("%w [price]", ErrPriceEdit)
+	case request.Fees != nil && !reflect.DeepEqual(request.Fees, storedFees):
+		return fmt.Errorf("%w [fees]", ErrPriceEdit)
+	}
 
-					offer.DataCenterResourceAttributtes.VPN.Speed = d
+	return nil
+}
 
-					break
-				}
+func (s *service) applyReadOptions(ctx context.Context, offers []model.Offer, options model.ReadOptions) error {
+	if options.ExpandSupplementaries {
+		if err := s.expandSupplementaries(ctx, offers); err != nil {
+			return err
+		}
+	}
 
-				offer.DataCenterResourceAttributtes.Bandwidth = s.checkBandwithNil(offer.DataCenterResourceAttributtes.Bandwidth)
+	if options.Currency != "" {
+		if err := s.convertOffers(ctx, offers, options.Currency); err != nil {
+			return err
+		}
+	}
 
-				offer.DataCenterResourceAttributtes.Bandwidth.Amount = d
+	return s.applyTaxes(ctx, offers, options.TaxVersion)
+}
 
-			case "C_RATE_UNIT":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+// expandSupplementaries resolves the supplementary offers of every offer with a single query
+func (s *service) expandSupplementaries(ctx context.Context, offers []model.Offer) error {
+	ids := make([]string, 0)
 
-				isVPN := s.checkifAccessTypeisVPN((*bssOffer.Attributes).Attribute)
+	for i := range offers {
+		ids = append(ids, offers[i].Supplementaries...)
+	}
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+	if len(ids) == 0 {
+		return nil
+	}
 
-				if isVPN {
-					offer.DataCenterResourceAttributtes.VPN = s.checkVPNNil(offer.DataCenterResourceAttributtes.VPN)
+	supplementaries, err := s.supplementaryRepository.GetByIDList(ctx, ids)
+	if err != nil {
+		return err
+	}
 
-					offer.DataCenterResourceAttributtes.VPN.Unit = attributte.Value
+	byID := make(map[string]model.Offer, len(supplementaries))
 
-					break
-				}
+	for i := range supplementaries {
+		byID[supplementaries[i].ID] = supplementaries[i]
+	}
 
-				offer.DataCenterResourceAttributtes.Bandwidth = s.checkBandwithNil(offer.DataCenterResourceAttributtes.Bandwidth)
+	for i := range offers {
+		offers[i].SupplementaryOffers = make([]model.Offer, 0, len(offers[i].Supplementaries))
 
-				offer.DataCenterResourceAttributtes.Bandwidth.Unit = attributte.Value
+		for _, id := range offers[i].Supplementaries {
+			if supOffer, ok := byID[id]; ok {
+				offers[i].SupplementaryOffers = append(offers[i].SupplementaryOffers, supOffer)
+			}
+		}
+	}
 
-				if offer.DataCenterResourceAttributtes.Bandwidth.Unit == "" {
-					offer.DataCenterResourceAttributtes.Bandwidth.Unit = "MB"
-				}
+	return nil
+}
 
-			case "CN_VPN_LANIP":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+func (s *service) GetDiagnostics(ctx context.Context, id string, appID string) (*model.OfferDiagnostics, error) {
+	offer, err := s.repository.GetByExternalID(ctx, id)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting offer [%s] diagnostics error [%s]", appID, id, err)
 
-				offer.DataCenterResourceAttributtes.VPN = s.checkVPNNil(offer.DataCenterResourceAttributtes.VPN)
+		s.logger.Error(msg)
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+		return nil, err
+	}
 
-				offer.DataCenterResourceAttributtes.VPN.IPAddress = attributte.Value
+	if offer == nil {
+		offer, err = s.supplementaryRepository.GetByExternalID(ctx, id)
+		if err != nil {
+			msg := fmt.Sprintf("[%s] getting supplementary offer [%s] diagnostics error [%s]", appID, id, err)
 
-			case "CN_VPN_NAME":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+			s.logger.Error(msg)
 
-				offer.DataCenterResourceAttributtes.VPN = s.checkVPNNil(offer.DataCenterResourceAttributtes.VPN)
+			return nil, err
+		}
+	}
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+	if offer == nil {
+		return nil, nil
+	}
 
-				offer.DataCenterResourceAttributtes.VPN.Name = attributte.Value
+	diagnostics := offer.Diagnostics
+	if diagnostics == nil {
+		diagnostics = []model.Diagnostic{}
+	}
 
-			case "CN_DNS":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+	return &model.OfferDiagnostics{
+		ID:          offer.ID,
+		ExternalID:  offer.ExternalID,
+		Name:        offer.Name,
+		Diagnostics: diagnostics,
+	}, nil
+}
 
-				offer.DataCenterResourceAttributtes.DNS = s.checkDNSNil(offer.DataCenterResourceAttributtes.DNS)
+func (s *service) GetPrimaryOffers(ctx context.Context, appID string, externalID string,
+	relationTypes []model.RelationType,
+) ([]model.Offer, error) {
+	offers, err := s.repository.GetBySupplementary(ctx, externalID, relationTypes)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting primary offers for supplementary [%s] error [%s]", appID, externalID, err)
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+		s.logger.Error(msg)
 
-				offer.DataCenterResourceAttributtes.DNS.DNS = attributte.Value
+		return nil, err
+	}
 
-			case "CN_DNS_CNAME":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+	return offers, nil
+}
 
-				offer.DataCenterResourceAttributtes.DNS = s.checkDNSNil(offer.DataCenterResourceAttributtes.DNS)
+func (s *service) GetCatalogs(ctx context.Context, appID string) ([]model.Catalog, error) {
+	catalogs, err := s.catalogRepository.All(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting catalogs error [%s]", appID, err)
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+		s.logger.Error(msg)
 
-				offer.DataCenterResourceAttributtes.DNS.Name = attributte.Value
+		return nil, err
+	}
 
-			case "C_ACCESS_TYPE":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+	return catalogs, nil
+}
 
-				offer.DataCenterResourceAttributtes.Bandwidth = s.checkBandwithNil(offer.DataCenterResourceAttributtes.Bandwidth)
+func (s *service) GetCatalogOffers(ctx context.Context, id string, appID string) ([]model.Offer, error) {
+	catalog, err := s.catalogRepository.Get(ctx, id)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting catalog [%s] error [%s]", appID, id, err)
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+		s.logger.Error(msg)
 
-				access := model.NationalAccess
+		return nil, err
+	}
 
-				if attributte.Value == "1" {
-					access = model.InternationalAccess
-				}
+	if catalog == nil {
+		return nil, nil
+	}
 
-				offer.DataCenterResourceAttributtes.Bandwidth.Type = access
+	offers, err := s.repository.GetByCatalog(ctx, id)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting catalog [%s] offers error [%s]", appID, id, err)
 
-			case "CN_VPS_LANIP":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+		s.logger.Error(msg)
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+		return nil, err
+	}
 
-				offer.DataCenterResourceAttributtes.LANIPAddress = &attributte.Value
+	return offers, nil
+}
 
-			case "CN_VPS_WANIP":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+func (s *service) GetBundle(ctx context.Context, id string, appID string) (*model.Bundle, error) {
+	offer, err := s.repository.GetByExternalID(ctx, id)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting bundle [%s] error [%s]", appID, id, err)
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+		s.logger.Error(msg)
 
-				offer.DataCenterResourceAttributtes.WANIPAddress = &attributte.Value
+		return nil, err
+	}
 
-			case "C_SAVEVM_FALG":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+	if offer == nil || !offer.Bundle {
+		return nil, nil
+	}
 
-				value := false
+	ids := make([]string, 0, len(offer.Components))
 
-				if attributte.Value == "1" {
-					value = true
-				}
+	for i := range offer.Components {
+		ids = append(ids, offer.Components[i].ID)
+	}
 
-				if attributte.Type != "1" {
-					offer.DataCenterResourceAttributtes.Included = false
-				}
+	components := make([]model.Offer, 0)
 
-				offer.DataCenterResourceAttributtes.SaveVM = &value
+	if len(ids) > 0 {
+		components, err = s.repository.GetByIDList(ctx, ids)
+		if err != nil {
+			msg := fmt.Sprintf("[%s] getting bundle [%s] components error [%s]", appID, id, err)
 
-			case "C_TEMPPREPAID_FLAG":
-				if attributte.Value == "1" {
-					offer.Temporal = true
-				}
+			s.logger.Error(msg)
 
-			case "amount":
-				d, _ := strconv.ParseFloat(attributte.Value, 64)
+			return nil, err
+		}
+	}
+
+	pricing, err := s.bundlePricing(ctx, *offer, components)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] pricing bundle [%s] error [%s]", appID, id, err)
 
-				offer.Fare = d
+		s.logger.Error(msg)
 
-			case "measureId":
-				currency := "CUP"
+		return nil, err
+	}
 
-				offer.Currency = &currency
+	return &model.Bundle{
+		Offer:      *offer,
+		Components: components,
+		Pricing:    *pricing,
+	}, nil
+}
 
-			case "C_PROTOCOLO_PUERTO":
-				offer.DataCenterResourceAttributtes = s.checkDataCenterAttributesNil(offer.DataCenterResourceAttributtes)
+// bundlePricing adds up the component prices converted to the currency of the bundle fare, a price in a currency
+// without exchange rate can not be converted and fails with ErrUnknownCurrency
+func (s *service) bundlePricing(ctx context.Context, offer model.Offer, components []model.Offer,
+) (*model.BundlePricing, error) {
+	rates, err := s.exchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-				offer.DataCenterResourceAttributtes.Port = s.checkPortNil(offer.DataCenterResourceAttributtes.Port)
+	price := s.offerPrice(offer)
+	currency := price.Fare.Currency
 
-				offer.DataCenterResourceAttributtes.Port.Description = attributte.Value
-			}
+	convert := func(m model.Money, id string) (model.Money, error) {
+		if _, ok := rates[m.Currency]; !ok {
+			return model.Money{}, fmt.Errorf("%w [%s] of offer [%s]", ErrUnknownCurrency, m.Currency, id)
 		}
+
+		return convertMoney(m, rates, currency), nil
 	}
 
-	if bssOffer.EffectiveDate != nil {
-		offer.EffectiveDate = *bssOffer.EffectiveDate
+	if _, err = convert(price.Fare, offer.ID); err != nil {
+		return nil, err
 	}
 
-	if bssOffer.ExpirationDate != nil {
-		offer.ExpirationDate = *bssOffer.ExpirationDate
+	activationFare, err := convert(price.ActivationFare, offer.ID)
+	if err != nil {
+		return nil, err
 	}
 
-	return &offer, nil
-}
+	pricing := model.BundlePricing{
+		Fare:                     price.Fare,
+		ActivationFare:           activationFare,
+		ComponentsFare:           model.Money{Currency: currency},
+		ComponentsActivationFare: model.Money{Currency: currency},
+	}
 
-func (s *service) Get(ctx context.Context, id string, appID string) (*model.Offer, error) {
-	offer, err := s.repository.Get(ctx, id)
-	if err != nil {
-		msg := fmt.Sprintf("[%s] getting offer [%s] error [%s]", appID, id, err)
+	for i := range components {
+		componentPrice := s.offerPrice(components[i])
 
-		s.logger.Error(msg)
+		fare, err := convert(componentPrice.Fare, components[i].ID)
+		if err != nil {
+			return nil, err
+		}
 
-		return nil, err
+		activationFare, err := convert(componentPrice.ActivationFare, components[i].ID)
+		if err != nil {
+			return nil, err
+		}
+
+		pricing.ComponentsFare = pricing.ComponentsFare.Add(fare)
+		pricing.ComponentsActivationFare = pricing.ComponentsActivationFare.Add(activationFare)
 	}
 
-	return offer, nil
+	pricing.Savings = pricing.ComponentsFare.Sub(pricing.Fare)
+	pricing.ActivationSavings = pricing.ComponentsActivationFare.Sub(pricing.ActivationFare)
+
+	return &pricing, nil
 }
 
 func (s *service) GetSecondaryOffers(ctx context.Context, ids []string) ([]model.Offer, error) {
@@ -563,7 +2039,7 @@ func (s *service) GetSecondaryOffers(ctx context.Context, ids []string) ([]model
 
 	for i := range ids {
 		for j := range offers {
-			if ids[i] == *offers[j].ExternalID {
+			if ids[i] == offers[j].ID || (offers[j].ExternalID != nil && ids[i] == *offers[j].ExternalID) {
 				nOffers = append(nOffers, offers[j])
 
 				break
@@ -584,6 +2060,14 @@ func (s *service) checkDataCenterAttributesNil(v *model.DataCenterResourceAttrib
 	return v
 }
 
+func (s *service) checkYellowPagesAttributesNil(v *model.YellowPagesAttributes) *model.YellowPagesAttributes {
+	if v == nil {
+		return &model.YellowPagesAttributes{}
+	}
+
+	return v
+}
+
 func (s *service) checkDatabaseNil(v *model.Database) *model.Database {
 	if v == nil {
 		return &model.Database{}
@@ -649,3 +2133,51 @@ func (s *service) checkPortNil(v *model.Port) *model.Port {
 
 	return v
 }
+
+func (s *service) parseInt(offer *model.Offer, attributte model.BssAttribute) int {
+	d, err := strconv.Atoi(attributte.Value)
+	if err != nil && attributte.Value != "" {
+		s.addInvalidValueDiagnostic(offer, attributte, "value is not a valid integer")
+	}
+
+	return d
+}
+
+func (s *service) parseFloat(offer *model.Offer, attributte model.BssAttribute) float64 {
+	d, err := strconv.ParseFloat(attributte.Value, 64)
+	if err != nil && attributte.Value != "" {
+		s.addInvalidValueDiagnostic(offer, attributte, "value is not a valid number")
+	}
+
+	return d
+}
+
+func (s *service) parseFlag(offer *model.Offer, attributte model.BssAttribute) bool {
+	if attributte.Value != "" && attributte.Value != "0" && attributte.Value != "1" {
+		s.addInvalidValueDiagnostic(offer, attributte, "value is not a valid flag, expected 0 or 1")
+	}
+
+	return attributte.Value == "1"
+}
+
+func (s *service) checkUnit(offer *model.Offer, current string, attributte model.BssAttribute) string {
+	if current != "" && attributte.Value != "" && current != attributte.Value {
+		offer.Diagnostics = append(offer.Diagnostics, model.Diagnostic{
+			Type:    model.ConflictingUnitDiagnostic,
+			Code:    attributte.Code,
+			Value:   attributte.Value,
+			Message: fmt.Sprintf("unit conflicts with previously mapped unit [%s]", current),
+		})
+	}
+
+	return attributte.Value
+}
+
+func (s *service) addInvalidValueDiagnostic(offer *model.Offer, attributte model.BssAttribute, message string) {
+	offer.Diagnostics = append(offer.Diagnostics, model.Diagnostic{
+		Type:    model.InvalidValueDiagnostic,
+		Code:    attributte.Code,
+		Value:   attributte.Value,
+		Message: message,
+	})
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"github.com/srrmendez/private-api-offers/repository"
+)
+
+func storedEditOffer(t *testing.T, s *service) model.Offer {
+	externalID := "web-1"
+	fare := model.Money{Cents: 1250, Currency: "USD"}
+
+	offer, err := s.repository.UpsertByExternalID(context.Background(), model.Offer{
+		ExternalID: &externalID,
+		Name:       "Web hosting",
+		Fare:       12.5,
+		Price:      &model.OfferPrice{Fare: fare, ActivationFare: model.Money{Currency: "USD"}},
+		Fees:       []model.FeeComponent{{Type: model.MonthlyFee, Amount: fare, Source: "monthly_fee"}},
+		DataCenterResourceAttributtes: &model.DataCenterResourceAttributtes{
+			HDD: &model.HDD{Amount: 10, Unit: "GB", Bytes: 10 << 30},
+		},
+	})
+	if err != nil {
+		t.Fatalf("storing offer error [%s]", err)
+	}
+
+	return *offer
+}
+
+// editRequest decodes the offer as the api returns it, the way a client sends back a read offer
+func editRequest(t *testing.T, offer model.Offer) model.OfferEditRequest {
+	raw, err := json.Marshal(offer)
+	if err != nil {
+		t.Fatalf("marshal error [%s]", err)
+	}
+
+	var request model.OfferEditRequest
+
+	if err = json.Unmarshal(raw, &request); err != nil {
+		t.Fatalf("unmarshal error [%s]", err)
+	}
+
+	request.ID = *offer.ExternalID
+	request.Version = offer.Version
+
+	return request
+}
+
+func TestSaveOfferKeepsPrices(t *testing.T) {
+	s := &service{repository: repository.NewMemoryRepository()}
+	stored := storedEditOffer(t, s)
+
+	read := stored
+	read.Name = "Web hosting plus"
+	read.DataCenterResourceAttributtes = &model.DataCenterResourceAttributtes{HDD: &model.HDD{Amount: 1, Unit: "tb"}}
+
+	offer, err := s.SaveOffer(context.Background(), "test", editRequest(t, read))
+	if err != nil {
+		t.Fatalf("save error [%s]", err)
+	}
+
+	if offer.Name != "Web hosting plus" {
+		t.Errorf("name = %s, want Web hosting plus", offer.Name)
+	}
+
+	if !reflect.DeepEqual(offer.Price, stored.Price) || !reflect.DeepEqual(offer.Fees, stored.Fees) {
+		t.Errorf("prices = %+v %+v, want %+v %+v", offer.Price, offer.Fees, stored.Price, stored.Fees)
+	}
+
+	if hdd := offer.DataCenterResourceAttributtes.HDD; hdd.Unit != "TB" || hdd.Bytes != 1<<40 {
+		t.Errorf("hdd = %s %d bytes, want TB %d bytes", hdd.Unit, hdd.Bytes, int64(1<<40))
+	}
+
+	if offer.ID != stored.ID || *offer.ExternalID != *stored.ExternalID {
+		t.Errorf("offer ids = %s %s, want %s %s", offer.ID, *offer.ExternalID, stored.ID, *stored.ExternalID)
+	}
+}
+
+func TestSaveOfferRejectsInvalidEdits(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(offer *model.Offer)
+		wantErr error
+	}{
+		{
+			name:    "price",
+			edit:    func(offer *model.Offer) { offer.Price.Fare.Cents = 999 },
+			wantErr: ErrPriceEdit,
+		},
+		{
+			name:    "fees",
+			edit:    func(offer *model.Offer) { offer.Fees = nil },
+			wantErr: nil,
+		},
+		{
+			name: "fee amount",
+			edit: func(offer *model.Offer) {
+				offer.Fees = []model.FeeComponent{{Type: model.MonthlyFee, Amount: model.Money{Cents: 1, Currency: "USD"}}}
+			},
+			wantErr: ErrPriceEdit,
+		},
+		{
+			name:    "fare",
+			edit:    func(offer *model.Offer) { offer.Fare = 1 },
+			wantErr: ErrPriceEdit,
+		},
+		{
+			name: "unknown unit",
+			edit: func(offer *model.Offer) {
+				offer.DataCenterResourceAttributtes = &model.DataCenterResourceAttributtes{HDD: &model.HDD{Amount: 1, Unit: "PB"}}
+			},
+			wantErr: ErrUnknownUnit,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &service{repository: repository.NewMemoryRepository()}
+			read := storedEditOffer(t, s)
+
+			price := *read.Price
+			read.Price = &price
+			read.Fees = append([]model.FeeComponent{}, read.Fees...)
+
+			test.edit(&read)
+
+			_, err := s.SaveOffer(context.Background(), "test", editRequest(t, read))
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("save error [%v], want [%v]", err, test.wantErr)
+			}
+		})
+	}
+}
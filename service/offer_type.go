@@ -0,0 +1,115 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+type offerTypeRule struct {
+	required  []string
+	forbidden []string
+}
+
+// offerTypeRules declare the data center attributes each offer type must and must not have, attributes are named
+// after their json field
+var offerTypeRules = map[model.OfferType]offerTypeRule{
+	model.OfferTypeVPS: {
+		required:  []string{"cpu_quantity", "ram", "hdd"},
+		forbidden: []string{"vpn", "dns"},
+	},
+	model.OfferTypeWebHosting: {
+		required:  []string{"hdd"},
+		forbidden: []string{"cpu_quantity", "ram", "vpn", "port"},
+	},
+	model.OfferTypeVirtualDataCenter: {
+		required:  []string{"cpu_quantity", "ram", "hdd"},
+		forbidden: []string{"dns"},
+	},
+	model.OfferTypeDedicatedServer: {
+		required:  []string{"cpu_quantity", "ram", "hdd"},
+		forbidden: []string{"vpn", "dns"},
+	},
+	model.OfferTypeHouseLeasing: {
+		forbidden: []string{"cpu_quantity", "ram", "hdd", "database", "ftp_quantity", "alias_quantity", "vpn", "dns"},
+	},
+	model.OfferTypeDNS: {
+		required:  []string{"dns"},
+		forbidden: []string{"cpu_quantity", "ram", "hdd", "database", "vpn", "bandwidth"},
+	},
+	model.OfferTypeACCESS: {
+		required:  []string{"bandwidth"},
+		forbidden: []string{"cpu_quantity", "ram", "hdd", "database", "vpn", "dns"},
+	},
+	model.OfferTypeVPN: {
+		required:  []string{"vpn"},
+		forbidden: []string{"cpu_quantity", "ram", "hdd", "database", "dns"},
+	},
+}
+
+// offerAttributes tell if an attribute is set with a meaningful value
+var offerAttributes = map[string]func(a model.DataCenterResourceAttributtes) bool{
+	"cpu_quantity": func(a model.DataCenterResourceAttributtes) bool { return positive(a.CPUQty) },
+	"ram":          func(a model.DataCenterResourceAttributtes) bool { return a.RAM != nil && a.RAM.Amount > 0 },
+	"hdd":          func(a model.DataCenterResourceAttributtes) bool { return a.HDD != nil && a.HDD.Amount > 0 },
+	"database": func(a model.DataCenterResourceAttributtes) bool {
+		return a.Database != nil && (a.Database.Quantity > 0 || a.Database.Amount > 0)
+	},
+	"ftp_quantity":          func(a model.DataCenterResourceAttributtes) bool { return positive(a.FTPQty) },
+	"alias_quantity":        func(a model.DataCenterResourceAttributtes) bool { return positive(a.AliasQty) },
+	"network_interface_qty": func(a model.DataCenterResourceAttributtes) bool { return positive(a.NetworkInterfaceQty) },
+	"vpn":                   func(a model.DataCenterResourceAttributtes) bool { return a.VPN != nil },
+	"dns":                   func(a model.DataCenterResourceAttributtes) bool { return a.DNS != nil },
+	"port":                  func(a model.DataCenterResourceAttributtes) bool { return a.Port != nil },
+	"bandwidth": func(a model.DataCenterResourceAttributtes) bool {
+		return a.Bandwidth != nil && a.Bandwidth.Amount > 0
+	},
+}
+
+// checkOfferType returns the attributes the offer is missing or must not have for its type, types without rules and
+// bundles, whose resources come from their components, are not checked
+func checkOfferType(offer model.Offer) []model.AttributeViolation {
+	rule, ok := offerTypeRules[offer.Type]
+	if !ok || offer.Bundle {
+		return nil
+	}
+
+	var attributes model.DataCenterResourceAttributtes
+
+	if offer.DataCenterResourceAttributtes != nil {
+		attributes = *offer.DataCenterResourceAttributtes
+	}
+
+	violations := make([]model.AttributeViolation, 0)
+
+	for _, name := range rule.required {
+		if !offerAttributes[name](attributes) {
+			violations = append(violations, model.AttributeViolation{
+				Attribute: name,
+				Rule:      model.RequiredViolationRule,
+				Message:   fmt.Sprintf("%s offers require %s", offer.Type, name),
+			})
+		}
+	}
+
+	for _, name := range rule.forbidden {
+		if offerAttributes[name](attributes) {
+			violations = append(violations, model.AttributeViolation{
+				Attribute: name,
+				Rule:      model.ForbiddenViolationRule,
+				Message:   fmt.Sprintf("%s offers can not have %s", offer.Type, name),
+			})
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Attribute < violations[j].Attribute
+	})
+
+	return violations
+}
+
+func positive(v *int) bool {
+	return v != nil && *v > 0
+}
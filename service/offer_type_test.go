@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"github.com/srrmendez/private-api-offers/repository"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestCheckOfferType(t *testing.T) {
+	server := model.DataCenterResourceAttributtes{
+		CPUQty: intPtr(2),
+		RAM:    &model.RAM{Amount: 4, Unit: "GB"},
+		HDD:    &model.HDD{Amount: 100, Unit: "GB"},
+	}
+
+	tests := []struct {
+		name       string
+		offer      model.Offer
+		violations []model.AttributeViolation
+	}{
+		{
+			name:  "valid vps",
+			offer: model.Offer{Type: model.OfferTypeVPS, DataCenterResourceAttributtes: &server},
+		},
+		{
+			name:  "vps without attributes",
+			offer: model.Offer{Type: model.OfferTypeVPS},
+			violations: []model.AttributeViolation{
+				{Attribute: "cpu_quantity", Rule: model.RequiredViolationRule, Message: "VPS offers require cpu_quantity"},
+				{Attribute: "hdd", Rule: model.RequiredViolationRule, Message: "VPS offers require hdd"},
+				{Attribute: "ram", Rule: model.RequiredViolationRule, Message: "VPS offers require ram"},
+			},
+		},
+		{
+			name: "dns with a vpn block",
+			offer: model.Offer{Type: model.OfferTypeDNS, DataCenterResourceAttributtes: &model.DataCenterResourceAttributtes{
+				DNS: &model.DNS{Name: "example"},
+				VPN: &model.VPN{Name: "office"},
+			}},
+			violations: []model.AttributeViolation{
+				{Attribute: "vpn", Rule: model.ForbiddenViolationRule, Message: "DNS offers can not have vpn"},
+			},
+		},
+		{
+			name: "zero quantities are not set",
+			offer: model.Offer{Type: model.OfferTypeWebHosting, DataCenterResourceAttributtes: &model.DataCenterResourceAttributtes{
+				CPUQty: intPtr(0),
+				RAM:    &model.RAM{Amount: 0},
+				HDD:    &model.HDD{Amount: 10, Unit: "GB"},
+			}},
+		},
+		{
+			name:  "access without bandwidth amount",
+			offer: model.Offer{Type: model.OfferTypeACCESS, DataCenterResourceAttributtes: &model.DataCenterResourceAttributtes{Bandwidth: &model.BandWith{}}},
+			violations: []model.AttributeViolation{
+				{Attribute: "bandwidth", Rule: model.RequiredViolationRule, Message: "ACCESS offers require bandwidth"},
+			},
+		},
+		{
+			name:  "bundles are not checked",
+			offer: model.Offer{Type: model.OfferTypeVPS, Bundle: true},
+		},
+		{
+			name:  "types without rules are not checked",
+			offer: model.Offer{Type: model.OfferTypeYellowPages},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			violations := checkOfferType(test.offer)
+
+			if len(violations) == 0 && len(test.violations) == 0 {
+				return
+			}
+
+			if !reflect.DeepEqual(violations, test.violations) {
+				t.Errorf("violations = %+v, want %+v", violations, test.violations)
+			}
+		})
+	}
+}
+
+func TestOfferTypeRulesAreKnownAttributes(t *testing.T) {
+	for offerType, rule := range offerTypeRules {
+		for _, name := range append(append([]string{}, rule.required...), rule.forbidden...) {
+			if _, ok := offerAttributes[name]; !ok {
+				t.Errorf("%s rule uses unknown attribute %s", offerType, name)
+			}
+		}
+	}
+}
+
+func TestSyncFlagsStoredOfferBreakingTypeRules(t *testing.T) {
+	ctx := context.Background()
+
+	s := &service{
+		repository:              repository.NewMemoryRepository(),
+		supplementaryRepository: repository.NewMemoryRepository(),
+		serviceTypes: map[string]model.ServiceType{
+			"10": {Code: "10", Category: model.CategoryTypeDataCenter, Type: model.OfferTypeVPS},
+		},
+		settings: Settings{DefaultCurrency: "USD"},
+	}
+
+	bssOffer := func(attributes ...model.BssAttribute) model.BssOffer {
+		attributes = append(attributes, model.BssAttribute{Code: "C_PH2_SERVICE_TYPE", Value: "10"})
+
+		return model.BssOffer{
+			ID:          "vps-1",
+			Name:        "VPS",
+			PrimaryFlag: "1",
+			Attributes:  &model.BssAttributeList{Attribute: attributes},
+		}
+	}
+
+	valid := bssOffer(
+		model.BssAttribute{Code: "CN_CPU_NUM", Value: "2", Type: "1"},
+		model.BssAttribute{Code: "CN_RAM_SPACE", Value: "4", Type: "1"},
+		model.BssAttribute{Code: "C_DISK_SPACE", Value: "100", Type: "1"},
+	)
+
+	if _, err := s.syncPrimaryOffer(ctx, valid); err != nil {
+		t.Fatalf("sync error [%s]", err)
+	}
+
+	result, err := s.syncPrimaryOffer(ctx, bssOffer(model.BssAttribute{Code: "C_DISK_SPACE", Value: "100", Type: "1"}))
+	if err != nil {
+		t.Fatalf("sync error [%s]", err)
+	}
+
+	if result.Action != model.InvalidSyncAction {
+		t.Fatalf("action = %s, want %s", result.Action, model.InvalidSyncAction)
+	}
+
+	stored, err := s.repository.GetByExternalID(ctx, "vps-1")
+	if err != nil {
+		t.Fatalf("get error [%s]", err)
+	}
+
+	if stored.DataCenterResourceAttributtes.CPUQty == nil {
+		t.Error("the stored offer was replaced by the invalid version")
+	}
+
+	flagged := make([]string, 0)
+
+	for _, diagnostic := range stored.Diagnostics {
+		if diagnostic.Type == model.TypeViolationDiagnostic {
+			flagged = append(flagged, diagnostic.Code)
+		}
+	}
+
+	if !reflect.DeepEqual(flagged, []string{"cpu_quantity", "ram"}) {
+		t.Errorf("type violation diagnostics = %v, want [cpu_quantity ram]", flagged)
+	}
+
+	if _, err = s.syncPrimaryOffer(ctx, valid); err != nil {
+		t.Fatalf("sync error [%s]", err)
+	}
+
+	if stored, err = s.repository.GetByExternalID(ctx, "vps-1"); err != nil {
+		t.Fatalf("get error [%s]", err)
+	}
+
+	for _, diagnostic := range stored.Diagnostics {
+		if diagnostic.Type == model.TypeViolationDiagnostic {
+			t.Errorf("diagnostic %+v kept after a valid sync", diagnostic)
+		}
+	}
+}
+
+func TestSupplementaryOffersFollowTypeRules(t *testing.T) {
+	ctx := context.Background()
+
+	s := &service{
+		repository:              repository.NewMemoryRepository(),
+		supplementaryRepository: repository.NewMemoryRepository(),
+		serviceTypes: map[string]model.ServiceType{
+			"20": {Code: "20", Category: model.CategoryTypeDataCenter, Type: model.OfferTypeVPN},
+		},
+		settings: Settings{DefaultCurrency: "USD"},
+	}
+
+	bssOffer := func(attributes ...model.BssAttribute) model.BssOffer {
+		attributes = append(attributes,
+			model.BssAttribute{Code: "C_PH2_SERVICE_TYPE", Value: "20"},
+			model.BssAttribute{Code: "C_DATAC_ACCESS_TYPE", Value: "VPN", Type: "1"},
+		)
+
+		return model.BssOffer{
+			ID:         "vpn-1",
+			Name:       "VPN",
+			Attributes: &model.BssAttributeList{Attribute: attributes},
+		}
+	}
+
+	valid := bssOffer(
+		model.BssAttribute{Code: "C_RATE_NUM", Value: "10", Type: "1"},
+		model.BssAttribute{Code: "C_RATE_UNIT", Value: "Mbps", Type: "1"},
+	)
+
+	result, err := s.syncSupplementaryOffer(ctx, valid)
+	if err != nil {
+		t.Fatalf("sync error [%s]", err)
+	}
+
+	if result.Action != model.CreateSyncAction {
+		t.Fatalf("action = %s, want %s", result.Action, model.CreateSyncAction)
+	}
+
+	invalid := bssOffer(model.BssAttribute{Code: "CN_CPU_NUM", Value: "2", Type: "1"})
+
+	validation, err := s.validateOffer(ctx, invalid, map[string]bool{})
+	if err != nil {
+		t.Fatalf("validate error [%s]", err)
+	}
+
+	if validation.Action != model.InvalidSyncAction || len(validation.Violations) != 2 {
+		t.Errorf("validation %s with violations %+v, want the missing vpn and the forbidden cpu", validation.Action,
+			validation.Violations)
+	}
+
+	if result, err = s.syncSupplementaryOffer(ctx, invalid); err != nil {
+		t.Fatalf("sync error [%s]", err)
+	}
+
+	if result.Action != model.InvalidSyncAction {
+		t.Fatalf("action = %s, want %s", result.Action, model.InvalidSyncAction)
+	}
+
+	stored, err := s.supplementaryRepository.GetByExternalID(ctx, "vpn-1")
+	if err != nil {
+		t.Fatalf("get error [%s]", err)
+	}
+
+	if stored.DataCenterResourceAttributtes.VPN == nil || stored.DataCenterResourceAttributtes.CPUQty != nil {
+		t.Error("the stored supplementary was replaced by the invalid version")
+	}
+
+	flagged := make([]string, 0)
+
+	for _, diagnostic := range stored.Diagnostics {
+		if diagnostic.Type == model.TypeViolationDiagnostic {
+			flagged = append(flagged, diagnostic.Code)
+		}
+	}
+
+	if !reflect.DeepEqual(flagged, []string{"cpu_quantity", "vpn"}) {
+		t.Errorf("type violation diagnostics = %v, want [cpu_quantity vpn]", flagged)
+	}
+}
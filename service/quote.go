@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+var ErrInvalidQuote = errors.New("invalid quote request")
+
+// Quote prices the primary offer plus the selected supplementaries, nil is returned when the primary offer does not
+// exist
+func (s *service) Quote(ctx context.Context, appID string, request model.QuoteRequest) (*model.Quote, error) {
+	quote, err := s.quote(ctx, request)
+	if err != nil {
+		if errors.Is(err, ErrInvalidQuote) || errors.Is(err, ErrUnknownCurrency) {
+			return nil, err
+		}
+
+		msg := fmt.Sprintf("[%s] quoting offer [%s] error [%s]", appID, request.OfferID, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	return quote, nil
+}
+
+// GetQuote returns nil when the quote does not exist or its validity expired
+func (s *service) GetQuote(ctx context.Context, id string, appID string) (*model.Quote, error) {
+	quote, err := s.quoteRepository.Get(ctx, id)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting quote [%s] error [%s]", appID, id, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	if quote == nil || time.Now().After(quote.ExpiresAt) {
+		return nil, nil
+	}
+
+	return quote, nil
+}
+
+type quoteLine struct {
+	offer    model.Offer
+	relation model.RelationType
+	primary  bool
+	quantity int
+}
+
+func (s *service) quote(ctx context.Context, request model.QuoteRequest) (*model.Quote, error) {
+	months, err := billedMonths(request)
+	if err != nil {
+		return nil, err
+	}
+
+	offer, err := s.repository.Get(ctx, request.OfferID)
+	if err != nil {
+		return nil, err
+	}
+
+	if offer == nil {
+		return nil, nil
+	}
+
+	lines, err := s.quoteLines(ctx, *offer, request)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := strings.ToUpper(request.Currency)
+	if currency == "" {
+		currency = s.offerPrice(*offer).Fare.Currency
+	}
+
+	rates, err := s.exchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := rates[currency]; !ok {
+		return nil, fmt.Errorf("%w [%s]", ErrUnknownCurrency, currency)
+	}
+
+	ruleSet, err := s.taxRuleRepository.Latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	quote := model.Quote{
+		OfferID:       offer.ID,
+		BillingPeriod: request.BillingPeriod,
+		StartDate:     request.StartDate,
+		Currency:      currency,
+		Items:         make([]model.QuoteItem, 0, len(lines)),
+		Activation:    zeroTaxedAmount(currency),
+		Recurring:     zeroTaxedAmount(currency),
+		CreatedAt:     now.Format("2006-01-02 15:04:00"),
+		ExpiresAt:     now.Add(s.settings.QuoteValidity),
+	}
+
+	if ruleSet != nil {
+		quote.TaxVersion = ruleSet.Version
+	}
+
+	for _, line := range lines {
+		price := s.offerPrice(line.offer)
+
+		if _, ok := rates[price.Fare.Currency]; !ok {
+			return nil, fmt.Errorf("%w [%s] of offer [%s]", ErrUnknownCurrency, price.Fare.Currency, line.offer.ID)
+		}
+
+		unitFare := convertMoney(price.Fare, rates, currency)
+		unitActivation := convertMoney(price.ActivationFare, rates, currency)
+
+		lineMonths := months
+		if line.offer.Temporal {
+			lineMonths = big.NewRat(1, 1)
+		}
+
+		quantity := big.NewRat(int64(line.quantity), 1)
+
+		net := model.OfferPrice{
+			Fare:           model.NewMoney(new(big.Rat).Mul(new(big.Rat).Mul(unitFare.Rat(), quantity), lineMonths), currency),
+			ActivationFare: model.NewMoney(new(big.Rat).Mul(unitActivation.Rat(), quantity), currency),
+		}
+
+		item := model.QuoteItem{
+			OfferID:        line.offer.ID,
+			ExternalID:     line.offer.ExternalID,
+			Name:           line.offer.Name,
+			Primary:        line.primary,
+			Relation:       line.relation,
+			Quantity:       line.quantity,
+			Temporal:       line.offer.Temporal,
+			BilledMonths:   lineMonths.FloatString(4),
+			UnitFare:       unitFare,
+			UnitActivation: unitActivation,
+			Activation:     taxAmount(net.ActivationFare, new(big.Rat)),
+			Recurring:      taxAmount(net.Fare, new(big.Rat)),
+		}
+
+		if ruleSet != nil {
+			taxes := computeTaxes(*ruleSet, line.offer, &net)
+
+			item.Activation = taxes.ActivationFare
+			item.Recurring = taxes.Fare
+		}
+
+		quote.Items = append(quote.Items, item)
+		quote.Activation = addTaxedAmount(quote.Activation, item.Activation)
+		quote.Recurring = addTaxedAmount(quote.Recurring, item.Recurring)
+	}
+
+	quote.Total = addTaxedAmount(quote.Activation, quote.Recurring)
+
+	return s.quoteRepository.Insert(ctx, quote)
+}
+
+// quoteLines checks the selection against the primary offer relationships: every supplementary must be attachable,
+// mandatory ones must be selected and at most one exclusive one can be selected
+func (s *service) quoteLines(ctx context.Context, offer model.Offer, request model.QuoteRequest) ([]quoteLine, error) {
+	quantity, err := quoteQuantity(request.Quantity, offer.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []quoteLine{{offer: offer, primary: true, quantity: quantity}}
+
+	references := s.primaryReferences(offer, map[string]*model.Offer{})
+
+	ids := make([]string, 0, len(references))
+
+	for _, reference := range references {
+		ids = append(ids, reference.ID)
+	}
+
+	supplementaries := make([]model.Offer, 0)
+
+	if len(ids) > 0 {
+		supplementaries, err = s.supplementaryRepository.GetByIDList(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	byID := make(map[string]model.Offer, len(supplementaries))
+
+	for i := range supplementaries {
+		byID[supplementaries[i].ID] = supplementaries[i]
+	}
+
+	selected := make(map[string]bool, len(request.Supplementaries))
+	exclusive := 0
+
+	for _, item := range request.Supplementaries {
+		reference, ok := findReference(references, item.ID)
+		if !ok {
+			return nil, fmt.Errorf("%w supplementary [%s] can not be attached to offer [%s]", ErrInvalidQuote, item.ID,
+				offer.ID)
+		}
+
+		if selected[reference.ID] {
+			return nil, fmt.Errorf("%w supplementary [%s] selected more than once", ErrInvalidQuote, item.ID)
+		}
+
+		if reference.Type == model.BundledRelationType || reference.Type == model.UpgradePathRelationType {
+			return nil, fmt.Errorf("%w supplementary [%s] has relation [%s] and can not be added", ErrInvalidQuote,
+				item.ID, reference.Type)
+		}
+
+		supOffer, ok := byID[reference.ID]
+		if !ok {
+			return nil, fmt.Errorf("%w supplementary [%s] is not available yet", ErrInvalidQuote, item.ID)
+		}
+
+		quantity, err := quoteQuantity(item.Quantity, item.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if reference.Type == model.ExclusiveRelationType {
+			exclusive++
+		}
+
+		selected[reference.ID] = true
+
+		lines = append(lines, quoteLine{offer: supOffer, relation: reference.Type, quantity: quantity})
+	}
+
+	if exclusive > 1 {
+		return nil, fmt.Errorf("%w only one exclusive supplementary can be selected", ErrInvalidQuote)
+	}
+
+	missing := make([]string, 0)
+
+	for _, reference := range references {
+		if reference.Type == model.MandatoryRelationType && !selected[reference.ID] {
+			missing = append(missing, reference.ExternalID)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w mandatory supplementaries %v not selected", ErrInvalidQuote, missing)
+	}
+
+	return lines, nil
+}
+
+func findReference(references []model.SupplementaryReference, id string) (model.SupplementaryReference, bool) {
+	for _, reference := range references {
+		if reference.ID == id || (reference.ExternalID != "" && reference.ExternalID == id) {
+			return reference, true
+		}
+	}
+
+	return model.SupplementaryReference{}, false
+}
+
+func quoteQuantity(quantity int, id string) (int, error) {
+	if quantity < 0 {
+		return 0, fmt.Errorf("%w quantity of [%s] must be positive", ErrInvalidQuote, id)
+	}
+
+	if quantity == 0 {
+		return 1, nil
+	}
+
+	return quantity, nil
+}
+
+// billedMonths returns the billing period in months, the first month is prorated by the days left from the start date
+func billedMonths(request model.QuoteRequest) (*big.Rat, error) {
+	if request.BillingPeriod < 0 {
+		return nil, fmt.Errorf("%w billing period must be positive", ErrInvalidQuote)
+	}
+
+	period := int64(request.BillingPeriod)
+	if period == 0 {
+		period = 1
+	}
+
+	months := big.NewRat(period, 1)
+
+	if request.StartDate == "" {
+		return months, nil
+	}
+
+	start, err := time.Parse("2006-01-02", request.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w start date [%s] must be YYYY-MM-DD", ErrInvalidQuote, request.StartDate)
+	}
+
+	days := int64(time.Date(start.Year(), start.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day())
+	left := days - int64(start.Day()) + 1
+
+	return months.Sub(months, big.NewRat(days-left, days)), nil
+}
+
+// offerPrice returns the stored price, offers synced before prices were stored fall back to the float fares
+func (s *service) offerPrice(offer model.Offer) model.OfferPrice {
+	if offer.Price != nil {
+		return *offer.Price
+	}
+
+	currency := s.settings.DefaultCurrency
+	if offer.Currency != nil {
+		currency = *offer.Currency
+	}
+
+	return model.OfferPrice{
+		Fare:           model.MoneyFromFloat(offer.Fare, currency),
+		ActivationFare: model.MoneyFromFloat(offer.ActivationFare, currency),
+	}
+}
+
+func zeroTaxedAmount(currency string) model.TaxedAmount {
+	return taxAmount(model.Money{Currency: currency}, new(big.Rat))
+}
+
+func addTaxedAmount(a model.TaxedAmount, b model.TaxedAmount) model.TaxedAmount {
+	return model.TaxedAmount{
+		Net:   a.Net.Add(b.Net),
+		Tax:   a.Tax.Add(b.Tax),
+		Gross: a.Gross.Add(b.Gross),
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"github.com/srrmendez/private-api-offers/repository"
+)
+
+func TestBilledMonths(t *testing.T) {
+	tests := []struct {
+		name    string
+		request model.QuoteRequest
+		want    *big.Rat
+		wantErr bool
+	}{
+		{name: "default period", want: big.NewRat(1, 1)},
+		{name: "whole months", request: model.QuoteRequest{BillingPeriod: 3}, want: big.NewRat(3, 1)},
+		{name: "first day", request: model.QuoteRequest{BillingPeriod: 2, StartDate: "2026-02-01"}, want: big.NewRat(2, 1)},
+		{name: "half month", request: model.QuoteRequest{StartDate: "2026-04-16"}, want: big.NewRat(1, 2)},
+		{name: "partial first month", request: model.QuoteRequest{BillingPeriod: 3, StartDate: "2026-10-22"},
+			want: big.NewRat(2*31+10, 31)},
+		{name: "last day of a leap february", request: model.QuoteRequest{StartDate: "2024-02-29"},
+			want: big.NewRat(1, 29)},
+		{name: "negative period", request: model.QuoteRequest{BillingPeriod: -1}, wantErr: true},
+		{name: "invalid start date", request: model.QuoteRequest{StartDate: "22/10/2026"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		months, err := billedMonths(test.request)
+		if test.wantErr {
+			if !errors.Is(err, ErrInvalidQuote) {
+				t.Errorf("%s error [%v], want an invalid quote", test.name, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s error [%s]", test.name, err)
+		}
+
+		if months.Cmp(test.want) != 0 {
+			t.Errorf("%s months = %s, want %s", test.name, months.RatString(), test.want.RatString())
+		}
+	}
+}
+
+// newQuoteTestService stores a primary offer "vps" of 10.00 a month and 20.00 of activation with a supplementary per
+// relation type, temporal is a one time 5.00 charge
+func newQuoteTestService(t *testing.T) *service {
+	ctx := context.Background()
+
+	s := &service{
+		repository:              repository.NewMemoryRepository(),
+		supplementaryRepository: repository.NewMemoryRepository(),
+		exchangeRateRepository:  &memoryExchangeRates{},
+		taxRuleRepository:       &memoryTaxRules{},
+		quoteRepository:         &memoryQuotes{},
+		settings:                Settings{DefaultCurrency: "USD"},
+	}
+
+	supplementaries := []struct {
+		externalID string
+		relation   model.RelationType
+		temporal   bool
+	}{
+		{"backup", model.MandatoryRelationType, false},
+		{"linux", model.ExclusiveRelationType, false},
+		{"windows", model.ExclusiveRelationType, false},
+		{"setup", model.OptionalRelationType, true},
+		{"vps-pack", model.BundledRelationType, false},
+	}
+
+	primary := pricedOffer("", 1000, 2000, "USD")
+	primary.ExternalID = stringPtr("vps")
+
+	for _, supplementary := range supplementaries {
+		offer := pricedOffer("", 300, 0, "USD")
+		offer.Name = supplementary.externalID
+		offer.ExternalID = stringPtr(supplementary.externalID)
+
+		if supplementary.temporal {
+			offer.Temporal = true
+			offer.Price.Fare.Cents = 500
+		}
+
+		stored, err := s.supplementaryRepository.UpsertByExternalID(ctx, offer)
+		if err != nil {
+			t.Fatalf("upsert error [%s]", err)
+		}
+
+		primary.References = append(primary.References, model.SupplementaryReference{
+			ID:         stored.ID,
+			ExternalID: supplementary.externalID,
+			Type:       supplementary.relation,
+		})
+	}
+
+	if _, err := s.repository.UpsertByExternalID(ctx, primary); err != nil {
+		t.Fatalf("upsert error [%s]", err)
+	}
+
+	return s
+}
+
+func stringPtr(v string) *string {
+	return &v
+}
+
+func TestQuoteRelationships(t *testing.T) {
+	ctx := context.Background()
+
+	s := newQuoteTestService(t)
+
+	tests := []struct {
+		name            string
+		supplementaries []string
+		wantErr         bool
+	}{
+		{name: "mandatory selected", supplementaries: []string{"backup"}},
+		{name: "one exclusive", supplementaries: []string{"backup", "linux"}},
+		{name: "missing mandatory", supplementaries: []string{"linux"}, wantErr: true},
+		{name: "nothing selected", wantErr: true},
+		{name: "conflicting exclusives", supplementaries: []string{"backup", "linux", "windows"}, wantErr: true},
+		{name: "selected twice", supplementaries: []string{"backup", "backup"}, wantErr: true},
+		{name: "bundled relation", supplementaries: []string{"backup", "vps-pack"}, wantErr: true},
+		{name: "not attachable", supplementaries: []string{"backup", "unknown"}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		request := model.QuoteRequest{OfferID: "vps"}
+
+		for _, id := range test.supplementaries {
+			request.Supplementaries = append(request.Supplementaries, model.QuoteItemRequest{ID: id})
+		}
+
+		quote, err := s.quote(ctx, request)
+
+		if test.wantErr {
+			if !errors.Is(err, ErrInvalidQuote) {
+				t.Errorf("%s error [%v], want an invalid quote", test.name, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("%s error [%s]", test.name, err)
+		}
+
+		if len(quote.Items) != len(test.supplementaries)+1 {
+			t.Errorf("%s items = %d, want %d", test.name, len(quote.Items), len(test.supplementaries)+1)
+		}
+	}
+}
+
+func TestQuoteChargesTemporalLinesOnce(t *testing.T) {
+	ctx := context.Background()
+
+	s := newQuoteTestService(t)
+
+	tests := []struct {
+		name          string
+		request       model.QuoteRequest
+		wantRecurring map[string]int64
+		wantMonths    map[string]string
+		wantTotal     int64
+	}{
+		{
+			name: "three months",
+			request: model.QuoteRequest{
+				OfferID:         "vps",
+				BillingPeriod:   3,
+				Supplementaries: []model.QuoteItemRequest{{ID: "backup", Quantity: 2}, {ID: "setup"}},
+			},
+			wantRecurring: map[string]int64{"vps": 3000, "backup": 1800, "setup": 500},
+			wantMonths:    map[string]string{"vps": "3.0000", "backup": "3.0000", "setup": "1.0000"},
+			wantTotal:     2000 + 3000 + 1800 + 500,
+		},
+		{
+			name: "prorated two months",
+			request: model.QuoteRequest{
+				OfferID:         "vps",
+				BillingPeriod:   2,
+				StartDate:       "2026-04-16",
+				Supplementaries: []model.QuoteItemRequest{{ID: "backup"}, {ID: "setup"}},
+			},
+			wantRecurring: map[string]int64{"vps": 1500, "backup": 450, "setup": 500},
+			wantMonths:    map[string]string{"vps": "1.5000", "backup": "1.5000", "setup": "1.0000"},
+			wantTotal:     2000 + 1500 + 450 + 500,
+		},
+	}
+
+	for _, test := range tests {
+		quote, err := s.quote(ctx, test.request)
+		if err != nil {
+			t.Fatalf("%s error [%s]", test.name, err)
+		}
+
+		for _, item := range quote.Items {
+			if item.Recurring.Net.Cents != test.wantRecurring[*item.ExternalID] {
+				t.Errorf("%s %s recurring = %s, want %d cents", test.name, *item.ExternalID, item.Recurring.Net,
+					test.wantRecurring[*item.ExternalID])
+			}
+
+			if item.BilledMonths != test.wantMonths[*item.ExternalID] {
+				t.Errorf("%s %s billed months = %s, want %s", test.name, *item.ExternalID, item.BilledMonths,
+					test.wantMonths[*item.ExternalID])
+			}
+		}
+
+		if quote.Total.Net.Cents != test.wantTotal {
+			t.Errorf("%s total = %s, want %d cents", test.name, quote.Total.Net, test.wantTotal)
+		}
+	}
+}
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+var ErrInvalidRecommendation = errors.New("invalid recommendation request")
+
+// resources are the quantities an offer provides, storage in bytes and bandwidth in bits per second, bandwidth without
+// access type is national
+type resources struct {
+	cpu                    int64
+	ram                    int64
+	hdd                    int64
+	databases              int64
+	nationalBandwidth      int64
+	internationalBandwidth int64
+}
+
+func (r resources) add(o resources, quantity int64) resources {
+	r.cpu += o.cpu * quantity
+	r.ram += o.ram * quantity
+	r.hdd += o.hdd * quantity
+	r.databases += o.databases * quantity
+	r.nationalBandwidth += o.nationalBandwidth * quantity
+	r.internationalBandwidth += o.internationalBandwidth * quantity
+
+	return r
+}
+
+// bandwidth returns the bandwidth of the access type, any access type counts when none is given
+func (r resources) bandwidth(accessType model.AccessType) int64 {
+	switch accessType {
+	case model.InternationalAccess:
+		return r.internationalBandwidth
+	case model.NationalAccess:
+		return r.nationalBandwidth
+	}
+
+	return r.nationalBandwidth + r.internationalBandwidth
+}
+
+// Recommend returns the cheapest active data center offers providing the requirements, ranked by fare and then by
+// over provisioning
+func (s *service) Recommend(ctx context.Context, appID string, request model.RecommendRequest) ([]model.Recommendation, error) {
+	recommendations, err := s.recommend(ctx, request)
+	if err != nil {
+		if errors.Is(err, ErrInvalidRecommendation) || errors.Is(err, ErrUnknownCurrency) {
+			return nil, err
+		}
+
+		msg := fmt.Sprintf("[%s] recommending offers error [%s]", appID, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	return recommendations, nil
+}
+
+func (s *service) recommend(ctx context.Context, request model.RecommendRequest) ([]model.Recommendation, error) {
+	required, err := requiredResources(request.Requirements)
+	if err != nil {
+		return nil, err
+	}
+
+	currency := strings.ToUpper(request.Currency)
+	if currency == "" {
+		currency = s.settings.DefaultCurrency
+	}
+
+	rates, err := s.exchangeRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := rates[currency]; !ok {
+		return nil, fmt.Errorf("%w [%s]", ErrUnknownCurrency, currency)
+	}
+
+	active := true
+	category := model.CategoryTypeDataCenter
+
+	offers, err := s.search(ctx, &active, &category, model.SearchFilters{})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]model.Offer, 0, len(offers))
+
+	for _, offer := range offers {
+		if offer.Bundle || offer.DataCenterResourceAttributtes == nil {
+			continue
+		}
+
+		if request.ClientType != "" && offer.ClientType != request.ClientType {
+			continue
+		}
+
+		if request.PaymentMode != "" && request.PaymentMode != model.AllPayMode &&
+			offer.Paymentmode != model.AllPayMode && offer.Paymentmode != request.PaymentMode {
+			continue
+		}
+
+		candidates = append(candidates, offer)
+	}
+
+	supplementaries, err := s.candidateSupplementaries(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := make([]model.Recommendation, 0, len(candidates))
+
+	for _, offer := range candidates {
+		recommendation, ok := s.recommendOffer(offer, supplementaries, required, request.Requirements.AccessType,
+			request.FillWithSupplementaries, rates, currency)
+		if ok {
+			recommendations = append(recommendations, recommendation)
+		}
+	}
+
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if recommendations[i].Fare.Cents != recommendations[j].Fare.Cents {
+			return recommendations[i].Fare.Cents < recommendations[j].Fare.Cents
+		}
+
+		return recommendations[i].OverProvisioning < recommendations[j].OverProvisioning
+	})
+
+	limit := request.Limit
+	if limit <= 0 {
+		limit = 5
+	}
+
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+
+	return recommendations, nil
+}
+
+// candidateSupplementaries loads the supplementaries of every candidate with a single query
+func (s *service) candidateSupplementaries(ctx context.Context, candidates []model.Offer) (map[string]model.Offer, error) {
+	ids := make([]string, 0)
+
+	for _, offer := range candidates {
+		for _, reference := range s.primaryReferences(offer, map[string]*model.Offer{}) {
+			ids = append(ids, reference.ID)
+		}
+	}
+
+	byID := make(map[string]model.Offer)
+
+	if len(ids) == 0 {
+		return byID, nil
+	}
+
+	supplementaries, err := s.supplementaryRepository.GetByIDList(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range supplementaries {
+		byID[supplementaries[i].ID] = supplementaries[i]
+	}
+
+	return byID, nil
+}
+
+// recommendOffer adds the mandatory supplementaries and, when fill is set, the cheapest supplementaries covering each
+// missing resource, false is returned when the requirements can not be met
+func (s *service) recommendOffer(offer model.Offer, supplementaries map[string]model.Offer, required resources,
+	accessType model.AccessType, fill bool, rates map[string]*big.Rat, currency string,
+) (model.Recommendation, bool) {
+	price := s.offerPrice(offer)
+
+	if _, ok := rates[price.Fare.Currency]; !ok {
+		return model.Recommendation{}, false
+	}
+
+	recommendation := model.Recommendation{
+		Offer:          offer,
+		Fare:           convertMoney(price.Fare, rates, currency),
+		ActivationFare: convertMoney(price.ActivationFare, rates, currency),
+	}
+
+	provided := offerResources(offer)
+
+	attachable := make([]model.RecommendedSupplementary, 0)
+	exclusiveID := ""
+
+	for _, reference := range s.primaryReferences(offer, map[string]*model.Offer{}) {
+		supOffer, ok := supplementaries[reference.ID]
+		if !ok {
+			if reference.Type == model.MandatoryRelationType {
+				return model.Recommendation{}, false
+			}
+
+			continue
+		}
+
+		if _, ok := rates[s.offerPrice(supOffer).Fare.Currency]; !ok {
+			continue
+		}
+
+		supplementary := model.RecommendedSupplementary{Offer: supOffer, Relation: reference.Type, Quantity: 1}
+
+		switch reference.Type {
+		case model.MandatoryRelationType:
+			provided = provided.add(offerResources(supOffer), 1)
+
+			s.addRecommendedSupplementary(&recommendation, supplementary, rates, currency)
+		case model.BundledRelationType, model.UpgradePathRelationType:
+		default:
+			attachable = append(attachable, supplementary)
+		}
+	}
+
+	// every fill covers a whole gap so the loop ends after at most one fill per resource
+	for {
+		gaps := resourceGaps(provided, required, accessType)
+		if len(gaps) == 0 {
+			break
+		}
+
+		if !fill {
+			return model.Recommendation{}, false
+		}
+
+		gap := gaps[0]
+
+		best, quantity, ok := s.cheapestFill(attachable, gap, exclusiveID, rates, currency)
+		if !ok {
+			return model.Recommendation{}, false
+		}
+
+		if best.Relation == model.ExclusiveRelationType {
+			exclusiveID = best.Offer.ID
+		}
+
+		best.Quantity = int(quantity)
+
+		provided = provided.add(offerResources(best.Offer), quantity)
+
+		s.addRecommendedSupplementary(&recommendation, best, rates, currency)
+	}
+
+	recommendation.OverProvisioning = overProvisioning(provided, required, accessType)
+
+	return recommendation, true
+}
+
+func (s *service) addRecommendedSupplementary(recommendation *model.Recommendation,
+	supplementary model.RecommendedSupplementary, rates map[string]*big.Rat, currency string,
+) {
+	price := s.offerPrice(supplementary.Offer)
+	quantity := int64(supplementary.Quantity)
+
+	fare := convertMoney(price.Fare, rates, currency)
+	activationFare := convertMoney(price.ActivationFare, rates, currency)
+
+	recommendation.Fare.Cents += fare.Cents * quantity
+	recommendation.ActivationFare.Cents += activationFare.Cents * quantity
+
+	for i := range recommendation.Supplementaries {
+		if recommendation.Supplementaries[i].Offer.ID == supplementary.Offer.ID {
+			recommendation.Supplementaries[i].Quantity += supplementary.Quantity
+
+			return
+		}
+	}
+
+	recommendation.Supplementaries = append(recommendation.Supplementaries, supplementary)
+}
+
+// cheapestFill returns the supplementary and quantity covering the gap at the lowest monthly fare, only the already
+// chosen exclusive supplementary can be used once one was chosen
+func (s *service) cheapestFill(attachable []model.RecommendedSupplementary, gap resourceGap, exclusiveID string,
+	rates map[string]*big.Rat, currency string,
+) (model.RecommendedSupplementary, int64, bool) {
+	var (
+		best     model.RecommendedSupplementary
+		quantity int64
+		cost     int64
+		found    bool
+	)
+
+	for _, supplementary := range attachable {
+		if exclusiveID != "" && supplementary.Relation == model.ExclusiveRelationType &&
+			supplementary.Offer.ID != exclusiveID {
+			continue
+		}
+
+		supResources := offerResources(supplementary.Offer)
+
+		amount := gap.amount(supResources)
+		if amount <= 0 {
+			continue
+		}
+
+		n := (gap.missing + amount - 1) / amount
+
+		c := convertMoney(s.offerPrice(supplementary.Offer).Fare, rates, currency).Cents * n
+
+		if !found || c < cost {
+			best, quantity, cost, found = supplementary, n, c, true
+		}
+	}
+
+	return best, quantity, found
+}
+
+type resourceGap struct {
+	missing int64
+	amount  func(r resources) int64
+}
+
+// resourceGaps returns the missing quantities, a requirement without access type is met by any bandwidth
+func resourceGaps(provided resources, required resources, accessType model.AccessType) []resourceGap {
+	gaps := make([]resourceGap, 0)
+
+	for _, amount := range resourceAmounts(accessType) {
+		if missing := amount(required) - amount(provided); missing > 0 {
+			gaps = append(gaps, resourceGap{missing: missing, amount: amount})
+		}
+	}
+
+	return gaps
+}
+
+func resourceAmounts(accessType model.AccessType) []func(r resources) int64 {
+	return []func(r resources) int64{
+		func(r resources) int64 { return r.cpu },
+		func(r resources) int64 { return r.ram },
+		func(r resources) int64 { return r.hdd },
+		func(r resources) int64 { return r.databases },
+		func(r resources) int64 { return r.bandwidth(accessType) },
+	}
+}
+
+func overProvisioning(provided resources, required resources, accessType model.AccessType) float64 {
+	total := 0.0
+	count := 0
+
+	for _, amount := range resourceAmounts(accessType) {
+		if amount(required) <= 0 {
+			continue
+		}
+
+		total += float64(amount(provided)-amount(required)) / float64(amount(required))
+		count++
+	}
+
+	if count == 0 {
+		return 0
+	}
+
+	return math.Round(total/float64(count)*1000) / 1000
+}
+
+func offerResources(offer model.Offer) resources {
+	var r resources
+
+	attributes := offer.DataCenterResourceAttributtes
+	if attributes == nil {
+		return r
+	}
+
+	if attributes.CPUQty != nil {
+		r.cpu = int64(*attributes.CPUQty)
+	}
+
+	if attributes.RAM != nil {
+		r.ram, _ = model.StorageBytes(attributes.RAM.Amount, attributes.RAM.Unit)
+	}
+
+	if attributes.HDD != nil {
+		r.hdd, _ = model.StorageBytes(attributes.HDD.Amount, attributes.HDD.Unit)
+	}
+
+	if attributes.Database != nil {
+		r.databases = int64(attributes.Database.Quantity)
+	}
+
+	if attributes.Bandwidth != nil {
+		bitsPerSecond, _ := model.BandwidthBitsPerSecond(attributes.Bandwidth.Amount, attributes.Bandwidth.Unit)
+
+		if attributes.Bandwidth.Type == model.InternationalAccess {
+			r.internationalBandwidth = bitsPerSecond
+		} else {
+			r.nationalBandwidth = bitsPerSecond
+		}
+	}
+
+	return r
+}
+
+func requiredResources(requirements model.ResourceRequirements) (resources, error) {
+	r := resources{
+		cpu:       int64(requirements.CPU),
+		databases: int64(requirements.Databases),
+	}
+
+	if requirements.CPU < 0 || requirements.Databases < 0 {
+		return r, fmt.Errorf("%w quantities must be positive", ErrInvalidRecommendation)
+	}
+
+	if requirements.AccessType != "" && requirements.AccessType != model.InternationalAccess &&
+		requirements.AccessType != model.NationalAccess {
+		return r, fmt.Errorf("%w access type [%s]", ErrInvalidRecommendation, requirements.AccessType)
+	}
+
+	quantities := []struct {
+		name  string
+		value string
+		parse func(string) (int64, error)
+		dest  *int64
+	}{
+		{"ram", requirements.RAM, model.ParseStorage, &r.ram},
+		{"hdd", requirements.HDD, model.ParseStorage, &r.hdd},
+		{"bandwidth", requirements.Bandwidth, model.ParseBandwidth, &r.nationalBandwidth},
+	}
+
+	for _, q := range quantities {
+		if q.value == "" {
+			continue
+		}
+
+		v, err := q.parse(q.value)
+		if err != nil {
+			return r, fmt.Errorf("%w %s [%s]", ErrInvalidRecommendation, q.name, err)
+		}
+
+		*q.dest = v
+	}
+
+	if requirements.AccessType == model.InternationalAccess {
+		r.internationalBandwidth, r.nationalBandwidth = r.nationalBandwidth, 0
+	}
+
+	if r == (resources{}) {
+		return r, fmt.Errorf("%w at least one resource is required", ErrInvalidRecommendation)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"github.com/srrmendez/private-api-offers/repository"
+)
+
+func TestRecommendRanksActiveOffersMeetingTheRequirements(t *testing.T) {
+	ctx := context.Background()
+
+	s := &service{
+		repository:              repository.NewMemoryRepository(),
+		supplementaryRepository: repository.NewMemoryRepository(),
+		exchangeRateRepository:  &memoryExchangeRates{rates: []model.ExchangeRate{{Currency: "EUR", Rate: "0.5"}}},
+		settings:                Settings{DefaultCurrency: "USD"},
+	}
+
+	dataCenterOffer := func(externalID string, cents int64, currency string, cpu int, ramGB float64) model.Offer {
+		offer := pricedOffer("", cents, 0, currency)
+		offer.ExternalID = stringPtr(externalID)
+		offer.Name = externalID
+		offer.Category = model.CategoryTypeDataCenter
+		offer.ClientType = model.IndividualClienType
+		offer.DataCenterResourceAttributtes = &model.DataCenterResourceAttributtes{
+			CPUQty: &cpu,
+			RAM:    &model.RAM{Amount: ramGB, Unit: "GB"},
+		}
+
+		return offer
+	}
+
+	small := dataCenterOffer("small", 1000, "USD", 2, 2)
+
+	for _, supplementary := range []struct {
+		offer    model.Offer
+		relation model.RelationType
+	}{
+		{dataCenterOffer("ram-pack", 300, "USD", 0, 2), model.OptionalRelationType},
+		{pricedOffer("", 100, 0, "USD"), model.MandatoryRelationType},
+	} {
+		offer := supplementary.offer
+		if offer.ExternalID == nil {
+			offer.ExternalID = stringPtr("backup")
+			offer.Name = "backup"
+		}
+
+		stored, err := s.supplementaryRepository.UpsertByExternalID(ctx, offer)
+		if err != nil {
+			t.Fatalf("upsert error [%s]", err)
+		}
+
+		small.References = append(small.References, model.SupplementaryReference{
+			ID:         stored.ID,
+			ExternalID: *stored.ExternalID,
+			Type:       supplementary.relation,
+		})
+	}
+
+	expired := dataCenterOffer("expired", 100, "USD", 8, 16)
+	expired.ExpirationAt = time.Now().Add(-time.Hour).Unix()
+
+	corporate := dataCenterOffer("corporate", 500, "USD", 4, 8)
+	corporate.ClientType = model.CorporativeClienType
+
+	bundle := dataCenterOffer("bundle", 200, "USD", 4, 8)
+	bundle.Bundle = true
+
+	offers := []model.Offer{small, dataCenterOffer("large", 1000, "EUR", 4, 8), expired, corporate, bundle}
+
+	for _, offer := range offers {
+		if _, err := s.repository.UpsertByExternalID(ctx, offer); err != nil {
+			t.Fatalf("upsert error [%s]", err)
+		}
+	}
+
+	type ranked struct {
+		externalID string
+		fare       int64
+	}
+
+	requirements := model.ResourceRequirements{CPU: 2, RAM: "4GB"}
+
+	tests := []struct {
+		name    string
+		request model.RecommendRequest
+		want    []ranked
+	}{
+		{
+			name:    "offers short of a resource",
+			request: model.RecommendRequest{Requirements: requirements, ClientType: model.IndividualClienType},
+			want:    []ranked{{"large", 2000}},
+		},
+		{
+			name: "filled with supplementaries",
+			request: model.RecommendRequest{Requirements: requirements, ClientType: model.IndividualClienType,
+				FillWithSupplementaries: true},
+			want: []ranked{{"small", 1400}, {"large", 2000}},
+		},
+		{
+			name:    "any client type",
+			request: model.RecommendRequest{Requirements: requirements, FillWithSupplementaries: true},
+			want:    []ranked{{"corporate", 500}, {"small", 1400}, {"large", 2000}},
+		},
+		{
+			name: "limit and currency",
+			request: model.RecommendRequest{Requirements: requirements, FillWithSupplementaries: true, Currency: "eur",
+				Limit: 1},
+			want: []ranked{{"corporate", 250}},
+		},
+		{
+			name:    "nothing meets the requirements",
+			request: model.RecommendRequest{Requirements: model.ResourceRequirements{CPU: 16}},
+			want:    []ranked{},
+		},
+	}
+
+	for _, test := range tests {
+		recommendations, err := s.recommend(ctx, test.request)
+		if err != nil {
+			t.Fatalf("%s recommend error [%s]", test.name, err)
+		}
+
+		got := make([]ranked, 0, len(recommendations))
+
+		for _, recommendation := range recommendations {
+			got = append(got, ranked{*recommendation.Offer.ExternalID, recommendation.Fare.Cents})
+		}
+
+		if len(got) != len(test.want) {
+			t.Errorf("%s = %v, want %v", test.name, got, test.want)
+
+			continue
+		}
+
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%s = %v, want %v", test.name, got, test.want)
+
+				break
+			}
+		}
+	}
+
+	filled, err := s.recommend(ctx, tests[1].request)
+	if err != nil {
+		t.Fatalf("recommend error [%s]", err)
+	}
+
+	if supplementaries := filled[0].Supplementaries; len(supplementaries) != 2 ||
+		*supplementaries[0].Offer.ExternalID != "backup" || *supplementaries[1].Offer.ExternalID != "ram-pack" ||
+		supplementaries[1].Quantity != 1 {
+		t.Errorf("small supplementaries = %+v, want the mandatory backup and one ram pack", supplementaries)
+	}
+}
+
+func TestRecommendRejectsInvalidRequests(t *testing.T) {
+	s := &service{
+		repository:             repository.NewMemoryRepository(),
+		exchangeRateRepository: &memoryExchangeRates{},
+		settings:               Settings{DefaultCurrency: "USD"},
+	}
+
+	tests := []struct {
+		name    string
+		request model.RecommendRequest
+		wantErr error
+	}{
+		{name: "no resources", wantErr: ErrInvalidRecommendation},
+		{name: "negative cpu", request: model.RecommendRequest{Requirements: model.ResourceRequirements{CPU: -1}},
+			wantErr: ErrInvalidRecommendation},
+		{name: "unknown access type", request: model.RecommendRequest{
+			Requirements: model.ResourceRequirements{CPU: 1, AccessType: "LOCAL"},
+		}, wantErr: ErrInvalidRecommendation},
+		{name: "unknown currency", request: model.RecommendRequest{
+			Requirements: model.ResourceRequirements{CPU: 1},
+			Currency:     "EUR",
+		}, wantErr: ErrUnknownCurrency},
+	}
+
+	for _, test := range tests {
+		if _, err := s.recommend(context.Background(), test.request); !errors.Is(err, test.wantErr) {
+			t.Errorf("%s error [%v], want [%s]", test.name, err, test.wantErr)
+		}
+	}
+}
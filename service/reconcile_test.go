@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+func TestReconcileRemovesOffersMissingFromTheSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	snapshot := model.BssSyncOfferRequest{SyncOffers: []model.BSSOfferRequest{{Offer: vpsBssOffer("a")}}}
+
+	tests := []struct {
+		name      string
+		dryRun    bool
+		maxRatio  float64
+		wantErr   error
+		exceeded  bool
+		remaining []string
+	}{
+		{name: "dry run", dryRun: true, remaining: []string{"a", "b", "c", "hosting"}},
+		{name: "dry run over the threshold", dryRun: true, maxRatio: 0.5, exceeded: true,
+			remaining: []string{"a", "b", "c", "hosting"}},
+		{name: "over the threshold", maxRatio: 0.5, wantErr: ErrReconcileThreshold,
+			remaining: []string{"a", "b", "c", "hosting"}},
+		{name: "under the threshold", maxRatio: 0.7, exceeded: false, remaining: []string{"a", "hosting"}},
+		{name: "without threshold", remaining: []string{"a", "hosting"}},
+	}
+
+	for _, test := range tests {
+		s := newSyncTestService()
+		s.settings.MaxRemovalRatio = test.maxRatio
+
+		for _, id := range []string{"a", "b", "c"} {
+			if _, err := s.syncPrimaryOffer(ctx, vpsBssOffer(id)); err != nil {
+				t.Fatalf("%s sync error [%s]", test.name, err)
+			}
+		}
+
+		// offers outside the scopes of the snapshot are never removed
+		hosting := "hosting"
+
+		_, err := s.repository.UpsertByExternalID(ctx, model.Offer{
+			ExternalID: &hosting,
+			Name:       "Hosting",
+			Category:   model.CategoryTypeDataCenter,
+			Type:       model.OfferTypeWebHosting,
+		})
+		if err != nil {
+			t.Fatalf("%s upsert error [%s]", test.name, err)
+		}
+
+		report, err := s.reconcile(ctx, "TEST", snapshot, test.dryRun)
+		if !errors.Is(err, test.wantErr) {
+			t.Fatalf("%s reconcile error [%v], want [%v]", test.name, err, test.wantErr)
+		}
+
+		if report != nil {
+			removed := make([]string, 0, len(report.Removals))
+
+			for _, removal := range report.Removals {
+				removed = append(removed, removal.ExternalID)
+			}
+
+			sort.Strings(removed)
+
+			if len(removed) != 2 || removed[0] != "b" || removed[1] != "c" || report.InScope != 3 {
+				t.Errorf("%s removals = %v of %d in scope, want [b c] of 3", test.name, removed, report.InScope)
+			}
+
+			if report.ThresholdExceeded != test.exceeded {
+				t.Errorf("%s threshold exceeded = %t, want %t", test.name, report.ThresholdExceeded, test.exceeded)
+			}
+
+			if report.DryRun != test.dryRun || (report.Sync == nil) != test.dryRun {
+				t.Errorf("%s dry run = %t with sync %+v, want %t", test.name, report.DryRun, report.Sync, test.dryRun)
+			}
+		}
+
+		offers, err := s.repository.All(ctx)
+		if err != nil {
+			t.Fatalf("%s all error [%s]", test.name, err)
+		}
+
+		remaining := make([]string, 0, len(offers))
+
+		for _, offer := range offers {
+			remaining = append(remaining, *offer.ExternalID)
+		}
+
+		sort.Strings(remaining)
+
+		if len(remaining) != len(test.remaining) {
+			t.Errorf("%s remaining = %v, want %v", test.name, remaining, test.remaining)
+
+			continue
+		}
+
+		for i := range remaining {
+			if remaining[i] != test.remaining[i] {
+				t.Errorf("%s remaining = %v, want %v", test.name, remaining, test.remaining)
+
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+func (s *service) CheckReferences(ctx context.Context, appID string) (*model.ReferenceReport, error) {
+	report, err := s.checkReferences(ctx, false)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] checking supplementary references error [%s]", appID, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *service) ResolveReferences(ctx context.Context, appID string) (*model.ReferenceReport, error) {
+	report, err := s.checkReferences(ctx, true)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] resolving supplementary references error [%s]", appID, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// checkReferences walks every primary offer reference, when resolve is set the references are relinked to the
+// supplementary currently stored for their external id, missing ones get a pending placeholder and orphaned
+// supplementaries older than the grace period are removed
+func (s *service) checkReferences(ctx context.Context, resolve bool) (*model.ReferenceReport, error) {
+	primaries, err := s.repository.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	supplementaries, err := s.supplementaryRepository.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*model.Offer, len(supplementaries))
+	byExternalID := make(map[string]*model.Offer, len(supplementaries))
+
+	for i := range supplementaries {
+		byID[supplementaries[i].ID] = &supplementaries[i]
+
+		if supplementaries[i].ExternalID != nil {
+			byExternalID[*supplementaries[i].ExternalID] = &supplementaries[i]
+		}
+	}
+
+	report := model.ReferenceReport{
+		Dangling:  make([]model.DanglingReference, 0),
+		Orphans:   make([]model.OrphanSupplementary, 0),
+		Conflicts: make([]string, 0),
+	}
+
+	referenced := make(map[string]bool)
+
+	for i := range primaries {
+		references := s.primaryReferences(primaries[i], byID)
+		relinked := false
+
+		for j := range references {
+			supOffer := byExternalID[references[j].ExternalID]
+
+			if supOffer == nil && resolve && references[j].ExternalID != "" {
+				externalID := references[j].ExternalID
+
+				supOffer, err = s.supplementaryRepository.CreatePending(ctx, externalID)
+				if err != nil {
+					return nil, err
+				}
+
+				byID[supOffer.ID] = supOffer
+				byExternalID[externalID] = supOffer
+			}
+
+			if supOffer == nil {
+				report.Dangling = append(report.Dangling, model.DanglingReference{
+					PrimaryID:               primaries[i].ID,
+					PrimaryExternalID:       primaries[i].ExternalID,
+					SupplementaryID:         references[j].ID,
+					SupplementaryExternalID: references[j].ExternalID,
+					State:                   model.MissingReferenceState,
+				})
+
+				continue
+			}
+
+			referenced[supOffer.ID] = true
+
+			if resolve && references[j].ID != supOffer.ID {
+				references[j].ID = supOffer.ID
+				relinked = true
+			}
+
+			if supOffer.Pending {
+				report.Dangling = append(report.Dangling, model.DanglingReference{
+					PrimaryID:               primaries[i].ID,
+					PrimaryExternalID:       primaries[i].ExternalID,
+					SupplementaryID:         supOffer.ID,
+					SupplementaryExternalID: references[j].ExternalID,
+					State:                   model.PendingReferenceState,
+				})
+			}
+		}
+
+		if !resolve || (!relinked && len(primaries[i].References) == len(references)) {
+			continue
+		}
+
+		primaries[i].References = references
+		primaries[i].Supplementaries = make([]string, 0, len(references))
+
+		for j := range references {
+			primaries[i].Supplementaries = append(primaries[i].Supplementaries, references[j].ID)
+		}
+
+		if _, err = s.repository.Upsert(ctx, primaries[i]); err != nil {
+			if errors.Is(err, ErrVersionConflict) {
+				report.Conflicts = append(report.Conflicts, primaries[i].ID)
+
+				continue
+			}
+
+			return nil, err
+		}
+
+		report.Relinked++
+	}
+
+	graceLimit := time.Now().Add(-s.settings.OrphanGracePeriod)
+
+	for i := range supplementaries {
+		supOffer := &supplementaries[i]
+
+		if referenced[supOffer.ID] {
+			continue
+		}
+
+		orphan := model.OrphanSupplementary{
+			ID:         supOffer.ID,
+			ExternalID: supOffer.ExternalID,
+			Pending:    supOffer.Pending,
+		}
+
+		if resolve && supOffer.ExternalID != nil && (supOffer.Pending || s.settings.RemoveOrphans) &&
+			updatedBefore(*supOffer, graceLimit) {
+			if err = s.supplementaryRepository.RemoveByExternalID(ctx, *supOffer.ExternalID); err != nil {
+				return nil, err
+			}
+
+			orphan.Removed = true
+		}
+
+		report.Orphans = append(report.Orphans, orphan)
+	}
+
+	return &report, nil
+}
+
+// updatedBefore tells whether the offer was last updated before limit, the primaries are read before the
+// supplementaries so a supplementary a sync is linking right now looks unreferenced until the grace period ends
+func updatedBefore(offer model.Offer, limit time.Time) bool {
+	updatedAt, err := time.ParseInLocation("2006-01-02 15:04:00", offer.UpdatedAt, time.Local)
+	if err != nil {
+		return true
+	}
+
+	return updatedAt.Before(limit)
+}
+
+// primaryReferences returns the offer references, offers synced before references were stored only have the
+// supplementary ids so the external ids are recovered from the stored supplementaries
+func (s *service) primaryReferences(offer model.Offer, byID map[string]*model.Offer) []model.SupplementaryReference {
+	if len(offer.References) > 0 {
+		references := make([]model.SupplementaryReference, len(offer.References))
+		copy(references, offer.References)
+
+		return references
+	}
+
+	references := make([]model.SupplementaryReference, 0, len(offer.Supplementaries))
+
+	for _, id := range offer.Supplementaries {
+		reference := model.SupplementaryReference{ID: id}
+
+		if supOffer, ok := byID[id]; ok && supOffer.ExternalID != nil {
+			reference.ExternalID = *supOffer.ExternalID
+		}
+
+		references = append(references, reference)
+	}
+
+	return references
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+func TestResolveReferencesLinksPendingSupplementaries(t *testing.T) {
+	ctx := context.Background()
+
+	s := newSyncTestService()
+
+	primary := vpsBssOffer("vps-1")
+	primary.Relationships = &model.BssRelationshipList{Attached: []model.BssAttached{{ID: "ip-1", RelationType: "1"}}}
+
+	if _, err := s.syncPrimaryOffer(ctx, primary); err != nil {
+		t.Fatalf("sync error [%s]", err)
+	}
+
+	danglingStates := func(report *model.ReferenceReport) []model.ReferenceState {
+		states := make([]model.ReferenceState, 0, len(report.Dangling))
+
+		for _, dangling := range report.Dangling {
+			if dangling.SupplementaryExternalID != "ip-1" {
+				t.Errorf("dangling reference to [%s], want ip-1", dangling.SupplementaryExternalID)
+			}
+
+			states = append(states, dangling.State)
+		}
+
+		return states
+	}
+
+	report, err := s.CheckReferences(ctx, "TEST")
+	if err != nil {
+		t.Fatalf("check error [%s]", err)
+	}
+
+	if states := danglingStates(report); len(states) != 1 || states[0] != model.PendingReferenceState {
+		t.Errorf("dangling states = %v, want the pending placeholder", states)
+	}
+
+	// the placeholder is lost, the resolver stores a new one and relinks the primary to it
+	if err = s.supplementaryRepository.RemoveByExternalID(ctx, "ip-1"); err != nil {
+		t.Fatalf("remove error [%s]", err)
+	}
+
+	if report, err = s.CheckReferences(ctx, "TEST"); err != nil {
+		t.Fatalf("check error [%s]", err)
+	}
+
+	if states := danglingStates(report); len(states) != 1 || states[0] != model.MissingReferenceState {
+		t.Errorf("dangling states = %v, want the missing supplementary", states)
+	}
+
+	if report, err = s.ResolveReferences(ctx, "TEST"); err != nil {
+		t.Fatalf("resolve error [%s]", err)
+	}
+
+	if report.Relinked != 1 {
+		t.Errorf("relinked = %d, want 1", report.Relinked)
+	}
+
+	placeholder, err := s.supplementaryRepository.GetByExternalID(ctx, "ip-1")
+	if err != nil || placeholder == nil || !placeholder.Pending {
+		t.Fatalf("placeholder = %+v [%v], want a pending one", placeholder, err)
+	}
+
+	stored, err := s.repository.GetByExternalID(ctx, "vps-1")
+	if err != nil {
+		t.Fatalf("get error [%s]", err)
+	}
+
+	if stored.References[0].ID != placeholder.ID || stored.Supplementaries[0] != placeholder.ID {
+		t.Errorf("primary references %+v, want the placeholder %s", stored.References, placeholder.ID)
+	}
+
+	// syncing the supplementary fills the placeholder
+	if _, err = s.syncSupplementaryOffer(ctx, model.BssOffer{ID: "ip-1", Name: "Public IP"}); err != nil {
+		t.Fatalf("sync error [%s]", err)
+	}
+
+	if report, err = s.ResolveReferences(ctx, "TEST"); err != nil {
+		t.Fatalf("resolve error [%s]", err)
+	}
+
+	if len(report.Dangling) > 0 || report.Relinked > 0 || len(report.Orphans) > 0 {
+		t.Errorf("report = %+v, want the reference resolved", report)
+	}
+
+	linked, err := s.supplementaryRepository.GetByExternalID(ctx, "ip-1")
+	if err != nil {
+		t.Fatalf("get error [%s]", err)
+	}
+
+	if linked.ID != placeholder.ID || linked.Pending || linked.Name != "Public IP" {
+		t.Errorf("supplementary = %+v, want the synced placeholder", linked)
+	}
+}
+
+func TestResolveReferencesRemovesOrphans(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		removeOrphans bool
+		gracePeriod   time.Duration
+		wantRemoved   map[string]bool
+	}{
+		{name: "within the grace period", removeOrphans: true, gracePeriod: time.Hour,
+			wantRemoved: map[string]bool{}},
+		{name: "pending only", gracePeriod: -time.Minute,
+			wantRemoved: map[string]bool{"pending": true}},
+		{name: "every orphan", removeOrphans: true, gracePeriod: -time.Minute,
+			wantRemoved: map[string]bool{"pending": true, "synced": true}},
+	}
+
+	for _, test := range tests {
+		s := newSyncTestService()
+		s.settings.RemoveOrphans = test.removeOrphans
+		s.settings.OrphanGracePeriod = test.gracePeriod
+
+		if _, err := s.supplementaryRepository.CreatePending(ctx, "pending"); err != nil {
+			t.Fatalf("%s create pending error [%s]", test.name, err)
+		}
+
+		if _, err := s.syncSupplementaryOffer(ctx, model.BssOffer{ID: "synced", Name: "Synced"}); err != nil {
+			t.Fatalf("%s sync error [%s]", test.name, err)
+		}
+
+		report, err := s.ResolveReferences(ctx, "TEST")
+		if err != nil {
+			t.Fatalf("%s resolve error [%s]", test.name, err)
+		}
+
+		if len(report.Orphans) != 2 {
+			t.Errorf("%s orphans = %+v, want both supplementaries", test.name, report.Orphans)
+		}
+
+		for _, orphan := range report.Orphans {
+			if orphan.Removed != test.wantRemoved[*orphan.ExternalID] {
+				t.Errorf("%s orphan %s removed = %t, want %t", test.name, *orphan.ExternalID, orphan.Removed,
+					test.wantRemoved[*orphan.ExternalID])
+			}
+
+			stored, err := s.supplementaryRepository.GetByExternalID(ctx, *orphan.ExternalID)
+			if err != nil {
+				t.Fatalf("%s get error [%s]", test.name, err)
+			}
+
+			if (stored == nil) != orphan.Removed {
+				t.Errorf("%s orphan %s stored = %t, reported removed %t", test.name, *orphan.ExternalID, stored != nil,
+					orphan.Removed)
+			}
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/srrmendez/private-api-offers/conf"
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+var ErrInvalidServiceType = errors.New("service type category and type are required")
+
+// SeedServiceTypes stores the configured categories missing from the registry, entries edited through the api
+// are never overwritten
+func (s *service) SeedServiceTypes(ctx context.Context, confCategories map[string]conf.Category) error {
+	for code, category := range confCategories {
+		serviceType, err := s.serviceTypeRepository.Get(ctx, code)
+		if err != nil {
+			return err
+		}
+
+		if serviceType != nil {
+			continue
+		}
+
+		if _, err = s.serviceTypeRepository.Upsert(ctx, model.ServiceType{
+			Code:     code,
+			Category: category.Category,
+			Type:     category.Type,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return s.loadServiceTypes(ctx)
+}
+
+func (s *service) GetServiceTypes(ctx context.Context, appID string) ([]model.ServiceType, error) {
+	serviceTypes, err := s.serviceTypeRepository.All(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting service types error [%s]", appID, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	return serviceTypes, nil
+}
+
+func (s *service) SaveServiceType(ctx context.Context, appID string, serviceType model.ServiceType) (*model.ServiceType, error) {
+	if serviceType.Category == "" || serviceType.Type == "" {
+		return nil, ErrInvalidServiceType
+	}
+
+	stored, err := s.serviceTypeRepository.Get(ctx, serviceType.Code)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting service type [%s] error [%s]", appID, serviceType.Code, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	serviceType.CreatedAt = ""
+
+	if stored != nil {
+		serviceType.CreatedAt = stored.CreatedAt
+	}
+
+	nServiceType, err := s.serviceTypeRepository.Upsert(ctx, serviceType)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] saving service type [%s] error [%s]", appID, serviceType.Code, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	if err = s.loadServiceTypes(ctx); err != nil {
+		msg := fmt.Sprintf("[%s] loading service types error [%s]", appID, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	return nServiceType, nil
+}
+
+func (s *service) RemoveServiceType(ctx context.Context, appID string, code string) (bool, error) {
+	serviceType, err := s.serviceTypeRepository.Get(ctx, code)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting service type [%s] error [%s]", appID, code, err)
+
+		s.logger.Error(msg)
+
+		return false, err
+	}
+
+	if serviceType == nil {
+		return false, nil
+	}
+
+	if err = s.serviceTypeRepository.Remove(ctx, code); err != nil {
+		msg := fmt.Sprintf("[%s] removing service type [%s] error [%s]", appID, code, err)
+
+		s.logger.Error(msg)
+
+		return false, err
+	}
+
+	if err = s.loadServiceTypes(ctx); err != nil {
+		msg := fmt.Sprintf("[%s] loading service types error [%s]", appID, err)
+
+		s.logger.Error(msg)
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetCategories reads the registry snapshot, it is loaded on startup and refreshed by every sync and service type
+// change so searches do not hit the repository
+func (s *service) GetCategories(ctx context.Context, appID string) ([]model.CategoryType, error) {
+	s.serviceTypesMutex.RLock()
+	defer s.serviceTypesMutex.RUnlock()
+
+	found := make(map[model.CategoryType]bool)
+	categories := make([]model.CategoryType, 0)
+
+	for _, serviceType := range s.serviceTypes {
+		if found[serviceType.Category] {
+			continue
+		}
+
+		found[serviceType.Category] = true
+
+		categories = append(categories, serviceType.Category)
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i] < categories[j]
+	})
+
+	return categories, nil
+}
+
+// loadServiceTypes refreshes the registry snapshot used while mapping commercial system offers
+func (s *service) loadServiceTypes(ctx context.Context) error {
+	serviceTypes, err := s.serviceTypeRepository.All(ctx)
+	if err != nil {
+		return err
+	}
+
+	byCode := make(map[string]model.ServiceType, len(serviceTypes))
+
+	for i := range serviceTypes {
+		byCode[serviceTypes[i].Code] = serviceTypes[i]
+	}
+
+	s.serviceTypesMutex.Lock()
+	s.serviceTypes = byCode
+	s.serviceTypesMutex.Unlock()
+
+	return nil
+}
+
+func (s *service) serviceType(code string) (model.ServiceType, bool) {
+	s.serviceTypesMutex.RLock()
+	defer s.serviceTypesMutex.RUnlock()
+
+	serviceType, ok := s.serviceTypes[code]
+
+	return serviceType, ok
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/srrmendez/private-api-offers/model"
+	"github.com/srrmendez/private-api-offers/repository"
+)
+
+func newSyncTestService() *service {
+	return &service{
+		repository:              repository.NewMemoryRepository(),
+		supplementaryRepository: repository.NewMemoryRepository(),
+		catalogRepository:       &memoryCatalogs{},
+		serviceTypeRepository: &memoryServiceTypes{serviceTypes: []model.ServiceType{
+			{Code: "10", Category: model.CategoryTypeDataCenter, Type: model.OfferTypeVPS},
+		}},
+		serviceTypes: map[string]model.ServiceType{
+			"10": {Code: "10", Category: model.CategoryTypeDataCenter, Type: model.OfferTypeVPS},
+		},
+		settings: Settings{
+			DefaultCurrency: "USD",
+			RelationTypes:   map[string]model.RelationType{"1": model.OptionalRelationType},
+		},
+	}
+}
+
+func vpsBssOffer(id string) model.BssOffer {
+	return model.BssOffer{
+		ID:          id,
+		Name:        "VPS",
+		PrimaryFlag: "1",
+		Attributes: &model.BssAttributeList{Attribute: []model.BssAttribute{
+			{Code: "C_PH2_SERVICE_TYPE", Value: "10"},
+			{Code: "CN_CPU_NUM", Value: "2", Type: "1"},
+			{Code: "CN_RAM_SPACE", Value: "4", Type: "1"},
+			{Code: "C_DISK_SPACE", Value: "100", Type: "1"},
+		}},
+	}
+}
+
+func TestSyncRemovesDroppedRelationships(t *testing.T) {
+	ctx := context.Background()
+
+	s := newSyncTestService()
+
+	linked := vpsBssOffer("vps-1")
+	linked.Relationships = &model.BssRelationshipList{Attached: []model.BssAttached{{ID: "ip-1", RelationType: "1"}}}
+	linked.Catalogs = model.BssCatalogList{Catalogs: []model.BssCatalog{{ID: "web", Name: "Web"}}}
+
+	unlinked := vpsBssOffer("vps-1")
+
+	tests := []struct {
+		offer model.BssOffer
+		want  model.SyncAction
+	}{
+		{offer: linked, want: model.CreateSyncAction},
+		{offer: unlinked, want: model.UpdateSyncAction},
+		{offer: unlinked, want: model.UnchangedSyncAction},
+	}
+
+	for i, test := range tests {
+		result, err := s.syncPrimaryOffer(ctx, test.offer)
+		if err != nil {
+			t.Fatalf("sync %d error [%s]", i, err)
+		}
+
+		if result.Action != test.want {
+			t.Errorf("sync %d action = %s with changes %+v, want %s", i, result.Action, result.Changes, test.want)
+		}
+	}
+
+	stored, err := s.repository.GetByExternalID(ctx, "vps-1")
+	if err != nil {
+		t.Fatalf("get error [%s]", err)
+	}
+
+	if len(stored.References) > 0 || len(stored.Supplementaries) > 0 || len(stored.Catalogs) > 0 {
+		t.Errorf("stored offer keeps references %v, supplementaries %v and catalogs %v", stored.References,
+			stored.Supplementaries, stored.Catalogs)
+	}
+
+	if stored.Version != 2 {
+		t.Errorf("version = %d, want 2 after a single update", stored.Version)
+	}
+
+	primaries, err := s.repository.GetBySupplementary(ctx, "ip-1", nil)
+	if err != nil {
+		t.Fatalf("get by supplementary error [%s]", err)
+	}
+
+	if len(primaries) > 0 {
+		t.Errorf("the removed relationship still links %d primaries", len(primaries))
+	}
+
+	catalogOffers, err := s.repository.GetByCatalog(ctx, "web")
+	if err != nil {
+		t.Fatalf("get by catalog error [%s]", err)
+	}
+
+	if len(catalogOffers) > 0 {
+		t.Errorf("the removed catalog still groups %d offers", len(catalogOffers))
+	}
+}
+
+func TestSyncReportsWrites(t *testing.T) {
+	ctx := context.Background()
+
+	s := newSyncTestService()
+
+	valid := vpsBssOffer("vps-1")
+
+	diagnosed := vpsBssOffer("vps-1")
+	diagnosed.Attributes.Attribute = append(diagnosed.Attributes.Attribute, model.BssAttribute{Code: "C_UNKNOWN"})
+
+	invalid := vpsBssOffer("vps-1")
+	invalid.Attributes.Attribute = invalid.Attributes.Attribute[:1]
+
+	tests := []struct {
+		name        string
+		offer       model.BssOffer
+		wantAction  model.SyncAction
+		wantWritten bool
+	}{
+		{name: "created", offer: valid, wantAction: model.CreateSyncAction, wantWritten: true},
+		{name: "unchanged", offer: valid, wantAction: model.UnchangedSyncAction},
+		{name: "new diagnostics", offer: diagnosed, wantAction: model.UnchangedSyncAction, wantWritten: true},
+		{name: "same diagnostics", offer: diagnosed, wantAction: model.UnchangedSyncAction},
+		{name: "flagged violations", offer: invalid, wantAction: model.InvalidSyncAction, wantWritten: true},
+		{name: "same violations", offer: invalid, wantAction: model.InvalidSyncAction},
+	}
+
+	for _, test := range tests {
+		result, err := s.syncPrimaryOffer(ctx, test.offer)
+		if err != nil {
+			t.Fatalf("%s sync error [%s]", test.name, err)
+		}
+
+		if result.Action != test.wantAction || result.Written != test.wantWritten {
+			t.Errorf("%s = %s written %t, want %s written %t", test.name, result.Action, result.Written,
+				test.wantAction, test.wantWritten)
+		}
+	}
+}
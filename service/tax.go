@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/srrmendez/private-api-offers/conf"
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+var (
+	ErrUnknownTaxVersion = errors.New("tax rules version not found")
+	ErrInvalidTaxRule    = errors.New("tax rule name is required and rate must be a decimal between 0 and 1")
+)
+
+// SeedTaxRules stores the configured rules as the first version when no version exists yet
+func (s *service) SeedTaxRules(ctx context.Context, confRules []conf.TaxRule) error {
+	latest, err := s.taxRuleRepository.Latest(ctx)
+	if err != nil {
+		return err
+	}
+
+	if latest != nil || len(confRules) == 0 {
+		return nil
+	}
+
+	rules := make([]model.TaxRule, 0, len(confRules))
+
+	for _, rule := range confRules {
+		rules = append(rules, model.TaxRule{
+			Name:        rule.Name,
+			Category:    rule.Category,
+			ClientType:  rule.ClientType,
+			PaymentMode: rule.PaymentMode,
+			Rate:        rule.Rate,
+		})
+	}
+
+	_, err = s.createTaxRules(ctx, rules)
+
+	return err
+}
+
+// GetTaxRules returns the requested version, version 0 returns the version in use
+func (s *service) GetTaxRules(ctx context.Context, appID string, version int) (*model.TaxRuleSet, error) {
+	ruleSet, err := s.taxRuleSet(ctx, version)
+	if err != nil {
+		msg := fmt.Sprintf("[%s] getting tax rules version [%d] error [%s]", appID, version, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	return ruleSet, nil
+}
+
+func (s *service) CreateTaxRules(ctx context.Context, appID string, rules []model.TaxRule) (*model.TaxRuleSet, error) {
+	ruleSet, err := s.createTaxRules(ctx, rules)
+	if err != nil {
+		if errors.Is(err, ErrInvalidTaxRule) {
+			return nil, err
+		}
+
+		msg := fmt.Sprintf("[%s] creating tax rules error [%s]", appID, err)
+
+		s.logger.Error(msg)
+
+		return nil, err
+	}
+
+	return ruleSet, nil
+}
+
+func (s *service) createTaxRules(ctx context.Context, rules []model.TaxRule) (*model.TaxRuleSet, error) {
+	for _, rule := range rules {
+		rate, ok := new(big.Rat).SetString(rule.Rate)
+		if rule.Name == "" || !ok || rate.Sign() < 0 || rate.Cmp(big.NewRat(1, 1)) > 0 {
+			return nil, fmt.Errorf("%w [%s]", ErrInvalidTaxRule, rule.Name)
+		}
+	}
+
+	latest, err := s.taxRuleRepository.Latest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	version := 1
+
+	if latest != nil {
+		version = latest.Version + 1
+	}
+
+	return s.taxRuleRepository.Insert(ctx, model.TaxRuleSet{
+		Version:   version,
+		Rules:     rules,
+		CreatedAt: time.Now().Format("2006-01-02 15:04:00"),
+	})
+}
+
+func (s *service) taxRuleSet(ctx context.Context, version int) (*model.TaxRuleSet, error) {
+	if version == 0 {
+		return s.taxRuleRepository.Latest(ctx)
+	}
+
+	return s.taxRuleRepository.Get(ctx, version)
+}
+
+// applyTaxes sets the taxes of the offers and their expanded supplementaries with the given rules version,
+// version 0 uses the version in use and nothing is set while no rules were ever stored
+func (s *service) applyTaxes(ctx context.Context, offers []model.Offer, version int) error {
+	ruleSet, err := s.taxRuleSet(ctx, version)
+	if err != nil {
+		return err
+	}
+
+	if ruleSet == nil && version != 0 {
+		return fmt.Errorf("%w [%d]", ErrUnknownTaxVersion, version)
+	}
+
+	if ruleSet == nil {
+		return nil
+	}
+
+	for i := range offers {
+		offers[i].Taxes = computeTaxes(*ruleSet, offers[i], offers[i].Price)
+		offers[i].ConvertedTaxes = computeTaxes(*ruleSet, offers[i], offers[i].ConvertedPrice)
+
+		for j := range offers[i].SupplementaryOffers {
+			supOffer := &offers[i].SupplementaryOffers[j]
+
+			supOffer.Taxes = computeTaxes(*ruleSet, *supOffer, supOffer.Price)
+			supOffer.ConvertedTaxes = computeTaxes(*ruleSet, *supOffer, supOffer.ConvertedPrice)
+		}
+	}
+
+	return nil
+}
+
+func computeTaxes(ruleSet model.TaxRuleSet, offer model.Offer, price *model.OfferPrice) *model.OfferTaxes {
+	if price == nil {
+		return nil
+	}
+
+	taxes := model.OfferTaxes{
+		Version: ruleSet.Version,
+		Rules:   make([]string, 0),
+	}
+
+	rate, names := taxRate(ruleSet.Rules, offer)
+	taxes.Rules = append(taxes.Rules, names...)
+
+	taxes.Fare = taxAmount(price.Fare, rate)
+	taxes.ActivationFare = taxAmount(price.ActivationFare, rate)
+
+	return &taxes
+}
+
+// taxRate sums the rates of the rules matching the offer, an offer sold in every payment mode can be bought in
+// either of them so it is taxed with the payment mode that has the highest rate
+func taxRate(rules []model.TaxRule, offer model.Offer) (*big.Rat, []string) {
+	if offer.Paymentmode != model.AllPayMode {
+		return matchingRate(rules, offer)
+	}
+
+	var rate *big.Rat
+	var names []string
+
+	for _, paymentMode := range []model.PayModeType{model.PrepaidPayMode, model.PostpaidPayMode} {
+		offer.Paymentmode = paymentMode
+
+		modeRate, modeNames := matchingRate(rules, offer)
+
+		if rate == nil || modeRate.Cmp(rate) > 0 {
+			rate, names = modeRate, modeNames
+		}
+	}
+
+	return rate, names
+}
+
+func matchingRate(rules []model.TaxRule, offer model.Offer) (*big.Rat, []string) {
+	rate := new(big.Rat)
+	names := make([]string, 0)
+
+	for _, rule := range rules {
+		if !taxRuleMatches(rule, offer) {
+			continue
+		}
+
+		r, ok := new(big.Rat).SetString(rule.Rate)
+		if !ok {
+			continue
+		}
+
+		rate.Add(rate, r)
+
+		names = append(names, rule.Name)
+	}
+
+	return rate, names
+}
+
+// taxRuleMatches tells whether the rule applies to the offer, a rule for ALL payment modes applies to every offer
+func taxRuleMatches(rule model.TaxRule, offer model.Offer) bool {
+	return (rule.Category == "" || rule.Category == offer.Category) &&
+		(rule.ClientType == "" || rule.ClientType == offer.ClientType) &&
+		(rule.PaymentMode == "" || rule.PaymentMode == model.AllPayMode || rule.PaymentMode == offer.Paymentmode)
+}
+
+func taxAmount(net model.Money, rate *big.Rat) model.TaxedAmount {
+	tax := model.NewMoney(new(big.Rat).Mul(net.Rat(), rate), net.Currency)
+
+	return model.TaxedAmount{
+		Net:   net,
+		Tax:   tax,
+		Gross: net.Add(tax),
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+func TestComputeTaxesPaymentMode(t *testing.T) {
+	ruleSet := model.TaxRuleSet{
+		Version: 1,
+		Rules: []model.TaxRule{
+			{Name: "vat", Rate: "0.10"},
+			{Name: "prepaid", PaymentMode: model.PrepaidPayMode, Rate: "0.05"},
+			{Name: "postpaid", PaymentMode: model.PostpaidPayMode, Rate: "0.08"},
+			{Name: "every mode", PaymentMode: model.AllPayMode, Rate: "0.01"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		paymentMode model.PayModeType
+		wantRules   []string
+		wantTax     int64
+	}{
+		{
+			name:        "prepaid offer",
+			paymentMode: model.PrepaidPayMode,
+			wantRules:   []string{"vat", "prepaid", "every mode"},
+			wantTax:     160,
+		},
+		{
+			name:        "postpaid offer",
+			paymentMode: model.PostpaidPayMode,
+			wantRules:   []string{"vat", "postpaid", "every mode"},
+			wantTax:     190,
+		},
+		{
+			name:        "offer sold in every payment mode takes the highest taxed mode",
+			paymentMode: model.AllPayMode,
+			wantRules:   []string{"vat", "postpaid", "every mode"},
+			wantTax:     190,
+		},
+		{
+			name:      "offer without payment mode",
+			wantRules: []string{"vat", "every mode"},
+			wantTax:   110,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			offer := model.Offer{Paymentmode: test.paymentMode}
+			price := model.OfferPrice{Fare: model.Money{Cents: 1000, Currency: "USD"}}
+
+			taxes := computeTaxes(ruleSet, offer, &price)
+
+			if !reflect.DeepEqual(taxes.Rules, test.wantRules) {
+				t.Errorf("rules = %v, want %v", taxes.Rules, test.wantRules)
+			}
+
+			if taxes.Fare.Tax.Cents != test.wantTax {
+				t.Errorf("fare tax = %d, want %d", taxes.Fare.Tax.Cents, test.wantTax)
+			}
+		})
+	}
+}
+
+func TestComputeTaxesAllModesTie(t *testing.T) {
+	ruleSet := model.TaxRuleSet{
+		Rules: []model.TaxRule{
+			{Name: "prepaid", PaymentMode: model.PrepaidPayMode, Rate: "0.05"},
+			{Name: "postpaid", PaymentMode: model.PostpaidPayMode, Rate: "0.05"},
+		},
+	}
+
+	offer := model.Offer{Paymentmode: model.AllPayMode}
+	price := model.OfferPrice{Fare: model.Money{Cents: 1000, Currency: "USD"}}
+
+	taxes := computeTaxes(ruleSet, offer, &price)
+
+	if !reflect.DeepEqual(taxes.Rules, []string{"prepaid"}) || taxes.Fare.Tax.Cents != 50 {
+		t.Errorf("taxes = %v %d, want [prepaid] 50", taxes.Rules, taxes.Fare.Tax.Cents)
+	}
+}
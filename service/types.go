@@ -1,7 +1,10 @@
 package service
 
 import (
+	"container/list"
 	"context"
+	"sync"
+	"time"
 
 	"github.com/srrmendez/private-api-offers/conf"
 	"github.com/srrmendez/private-api-offers/model"
@@ -11,16 +14,102 @@ import (
 )
 
 type OfferService interface {
-	Search(ctx context.Context, appID string, active *bool, category *model.CategoryType) ([]model.Offer, error)
-	Sync(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest) error
-	Get(ctx context.Context, id string, appID string) (*model.Offer, error)
+	Search(ctx context.Context, appID string, active *bool, category *model.CategoryType, filters model.SearchFilters, options model.ReadOptions) ([]model.Offer, error)
+	Sync(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest) (*model.SyncReport, error)
+	Reconcile(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest, dryRun bool) (*model.ReconcileReport, error)
+	Validate(ctx context.Context, appID string, bssSyncOffer model.BssSyncOfferRequest) (*model.SyncValidation, error)
+	Get(ctx context.Context, id string, appID string, options model.ReadOptions) (*model.Offer, error)
+	SaveOffer(ctx context.Context, appID string, request model.OfferEditRequest) (*model.Offer, error)
 	GetSecondaryOffers(ctx context.Context, ids []string) ([]model.Offer, error)
+	SeedServiceTypes(ctx context.Context, confCategories map[string]conf.Category) error
+	GetServiceTypes(ctx context.Context, appID string) ([]model.ServiceType, error)
+	SaveServiceType(ctx context.Context, appID string, serviceType model.ServiceType) (*model.ServiceType, error)
+	RemoveServiceType(ctx context.Context, appID string, code string) (bool, error)
+	GetCategories(ctx context.Context, appID string) ([]model.CategoryType, error)
+	SeedExchangeRates(ctx context.Context, rates map[string]string) error
+	GetExchangeRates(ctx context.Context, appID string) ([]model.ExchangeRate, error)
+	SaveExchangeRate(ctx context.Context, appID string, rate model.ExchangeRate) (*model.ExchangeRate, error)
+	SeedTaxRules(ctx context.Context, confRules []conf.TaxRule) error
+	GetTaxRules(ctx context.Context, appID string, version int) (*model.TaxRuleSet, error)
+	CreateTaxRules(ctx context.Context, appID string, rules []model.TaxRule) (*model.TaxRuleSet, error)
+	Quote(ctx context.Context, appID string, request model.QuoteRequest) (*model.Quote, error)
+	GetQuote(ctx context.Context, id string, appID string) (*model.Quote, error)
+	Compare(ctx context.Context, appID string, ids []string, currency string) (*model.Comparison, error)
+	Recommend(ctx context.Context, appID string, request model.RecommendRequest) ([]model.Recommendation, error)
+	GetCatalogs(ctx context.Context, appID string) ([]model.Catalog, error)
+	GetCatalogOffers(ctx context.Context, id string, appID string) ([]model.Offer, error)
+	GetBundle(ctx context.Context, id string, appID string) (*model.Bundle, error)
+	GetPrimaryOffers(ctx context.Context, appID string, externalID string, relationTypes []model.RelationType) ([]model.Offer, error)
+	GetDiagnostics(ctx context.Context, id string, appID string) (*model.OfferDiagnostics, error)
+	CheckReferences(ctx context.Context, appID string) (*model.ReferenceReport, error)
+	ResolveReferences(ctx context.Context, appID string) (*model.ReferenceReport, error)
 }
 
 type service struct {
 	logger                  log.Log
 	repository              repository.OfferRepository
 	supplementaryRepository repository.OfferRepository
-	confCategories          map[string]conf.Category
+	catalogRepository       repository.CatalogRepository
+	serviceTypeRepository   repository.ServiceTypeRepository
+	serviceTypes            map[string]model.ServiceType
+	serviceTypesMutex       sync.RWMutex
+	exchangeRateRepository  repository.ExchangeRateRepository
+	taxRuleRepository       repository.TaxRuleRepository
+	quoteRepository         repository.QuoteRepository
 	trackingClient          tracking.TrackingClient
+	settings                Settings
+}
+
+// cachedService serves the Search and Get reads of the wrapped service from the cache, the writes changing offers drop
+// the reads they affect
+type cachedService struct {
+	OfferService
+	logger log.Log
+	local  *lruCache
+	shared repository.CacheRepository
+	ttl    time.Duration
+	// generation is increased on every invalidation, a read computed meanwhile is not cached
+	mutex      sync.Mutex
+	generation int64
+	// last generation of the shared cache seen, other instances increase it when they invalidate
+	sharedGeneration int64
+}
+
+// cacheGeneration is the state of the caches when a read started
+type cacheGeneration struct {
+	local  int64
+	shared int64
+}
+
+type lruCache struct {
+	mutex    sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type lruEntry struct {
+	key       string
+	value     interface{}
+	tags      []string
+	expiresAt time.Time
+}
+
+type Settings struct {
+	StrictMapping bool
+	// attribute code the commercial system sends the usage fee in, empty when it sends none
+	UsageAttribute string
+	// attribute codes the commercial system sends the max quantity and unit price of each billed resource in, keyed
+	// by resource
+	BillingAttributes map[string]conf.BillingAttribute
+	MaxRemovalRatio   float64
+	RemoveOrphans     bool
+	// time an unreferenced supplementary is kept after its last update before the resolver removes it
+	OrphanGracePeriod time.Duration
+	RelationTypes     map[string]model.RelationType
+	DefaultCurrency   string
+	// commercial system measure ids to ISO currency codes
+	Currencies map[string]string
+	// time a quote can be retrieved after being computed
+	QuoteValidity time.Duration
 }
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+func TestUnknownUnitDiagnosticCode(t *testing.T) {
+	s := &service{settings: Settings{DefaultCurrency: "USD"}}
+
+	tests := []struct {
+		name       string
+		attributes []model.BssAttribute
+		wantCode   string
+	}{
+		{
+			name: "bandwidth",
+			attributes: []model.BssAttribute{
+				{Code: "C_RATE_NUM", Value: "10", Type: "1"},
+				{Code: "C_RATE_UNIT", Value: "furlongs", Type: "1"},
+			},
+			wantCode: "C_RATE_UNIT",
+		},
+		{
+			name: "vpn speed",
+			attributes: []model.BssAttribute{
+				{Code: "C_DATAC_ACCESS_TYPE", Value: "VPN", Type: "1"},
+				{Code: "C_RATE_NUM", Value: "10", Type: "1"},
+				{Code: "C_RATE_UNIT", Value: "furlongs", Type: "1"},
+			},
+			wantCode: "C_RATE_UNIT",
+		},
+		{
+			name: "ram",
+			attributes: []model.BssAttribute{
+				{Code: "CN_RAM_SPACE", Value: "4", Type: "1"},
+				{Code: "C_RAM_SPACE_UNIT", Value: "PB", Type: "1"},
+			},
+			wantCode: "C_RAM_SPACE_UNIT",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			offer, err := s.mapBssOfferToOffer(model.BssOffer{
+				Attributes: &model.BssAttributeList{Attribute: test.attributes},
+			})
+			if err != nil {
+				t.Fatalf("mapping error [%s]", err)
+			}
+
+			codes := make([]string, 0)
+
+			for _, diagnostic := range offer.Diagnostics {
+				if diagnostic.Type == model.UnknownUnitDiagnostic {
+					codes = append(codes, diagnostic.Code)
+				}
+			}
+
+			if len(codes) != 1 || codes[0] != test.wantCode {
+				t.Errorf("unknown unit diagnostics = %v, want [%s]", codes, test.wantCode)
+			}
+		})
+	}
+}
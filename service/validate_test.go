@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+func TestValidateDoesNotWrite(t *testing.T) {
+	ctx := context.Background()
+
+	s := newSyncTestService()
+
+	if _, err := s.syncPrimaryOffer(ctx, vpsBssOffer("stored")); err != nil {
+		t.Fatalf("sync error [%s]", err)
+	}
+
+	renamed := vpsBssOffer("renamed")
+	renamed.Name = "Old"
+
+	if _, err := s.syncPrimaryOffer(ctx, renamed); err != nil {
+		t.Fatalf("sync error [%s]", err)
+	}
+
+	renamed.Name = "New"
+
+	linked := vpsBssOffer("new")
+	linked.Relationships = &model.BssRelationshipList{Attached: []model.BssAttached{{ID: "ip-1", RelationType: "1"}}}
+
+	invalid := vpsBssOffer("invalid")
+	invalid.Attributes.Attribute = invalid.Attributes.Attribute[:1]
+
+	retired := vpsBssOffer("stored")
+	retired.Status = model.RetirementBssStatus
+
+	tests := []struct {
+		name    string
+		offer   model.BssOffer
+		action  model.SyncAction
+		changes []string
+	}{
+		{name: "stored", offer: vpsBssOffer("stored"), action: model.UnchangedSyncAction},
+		{name: "renamed", offer: renamed, action: model.UpdateSyncAction, changes: []string{"name"}},
+		{name: "new", offer: linked, action: model.CreateSyncAction},
+		{name: "invalid", offer: invalid, action: model.InvalidSyncAction},
+		{name: "retired", offer: retired, action: model.RemoveSyncAction},
+	}
+
+	request := model.BssSyncOfferRequest{}
+
+	for _, test := range tests {
+		request.SyncOffers = append(request.SyncOffers, model.BSSOfferRequest{Offer: test.offer})
+	}
+
+	validation, err := s.Validate(ctx, "TEST", request)
+	if err != nil {
+		t.Fatalf("validate error [%s]", err)
+	}
+
+	if validation.Valid {
+		t.Error("validation is valid with an invalid offer")
+	}
+
+	for i, test := range tests {
+		offerValidation := validation.Offers[i]
+
+		if offerValidation.Action != test.action {
+			t.Errorf("%s action = %s with errors %v, want %s", test.name, offerValidation.Action, offerValidation.Errors,
+				test.action)
+		}
+
+		changes := make([]string, 0, len(offerValidation.Changes))
+
+		for _, change := range offerValidation.Changes {
+			changes = append(changes, change.Field)
+		}
+
+		if len(changes) != len(test.changes) || len(changes) > 0 && changes[0] != test.changes[0] {
+			t.Errorf("%s changes = %v, want %v", test.name, changes, test.changes)
+		}
+	}
+
+	if errors := validation.Offers[3].Errors; len(errors) == 0 {
+		t.Error("the invalid offer has no errors")
+	}
+
+	offers, err := s.repository.All(ctx)
+	if err != nil {
+		t.Fatalf("all error [%s]", err)
+	}
+
+	if len(offers) != 2 {
+		t.Errorf("%d offers stored after validating, want the 2 synced before", len(offers))
+	}
+
+	for _, offer := range offers {
+		if *offer.ExternalID == "renamed" && offer.Name != "Old" {
+			t.Errorf("validating renamed the stored offer to %s", offer.Name)
+		}
+	}
+
+	if pending, _ := s.supplementaryRepository.GetByExternalID(ctx, "ip-1"); pending != nil {
+		t.Error("validating created a pending supplementary")
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/srrmendez/private-api-offers/model"
+)
+
+func TestYellowPagesDurationMonths(t *testing.T) {
+	s := &service{settings: Settings{DefaultCurrency: "USD"}}
+
+	tests := []struct {
+		name            string
+		attributes      []model.BssAttribute
+		wantUnit        string
+		wantMonths      int
+		wantUnknownUnit bool
+	}{
+		{
+			name:       "months",
+			attributes: []model.BssAttribute{{Code: "CN_YP_DURATION", Value: "12"}, {Code: "C_YP_DURATION_UNIT", Value: "month"}},
+			wantUnit:   "MONTH",
+			wantMonths: 12,
+		},
+		{
+			name:       "years",
+			attributes: []model.BssAttribute{{Code: "CN_YP_DURATION", Value: "1"}, {Code: "C_YP_DURATION_UNIT", Value: "YEARS"}},
+			wantUnit:   "YEAR",
+			wantMonths: 12,
+		},
+		{
+			name:       "no unit",
+			attributes: []model.BssAttribute{{Code: "CN_YP_DURATION", Value: "6"}},
+			wantUnit:   "MONTH",
+			wantMonths: 6,
+		},
+		{
+			name:            "unknown unit",
+			attributes:      []model.BssAttribute{{Code: "CN_YP_DURATION", Value: "6"}, {Code: "C_YP_DURATION_UNIT", Value: "FORTNIGHT"}},
+			wantUnit:        "FORTNIGHT",
+			wantUnknownUnit: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			offer, err := s.mapBssOfferToOffer(model.BssOffer{
+				Attributes: &model.BssAttributeList{Attribute: test.attributes},
+			})
+			if err != nil {
+				t.Fatalf("mapping error [%s]", err)
+			}
+
+			attributes := offer.YellowPagesAttributes
+
+			if attributes.DurationUnit != test.wantUnit || attributes.Months != test.wantMonths {
+				t.Errorf("duration = %s %d months, want %s %d months", attributes.DurationUnit, attributes.Months,
+					test.wantUnit, test.wantMonths)
+			}
+
+			unknownUnit := false
+
+			for _, diagnostic := range offer.Diagnostics {
+				if diagnostic.Type == model.UnknownUnitDiagnostic && diagnostic.Code == "C_YP_DURATION_UNIT" {
+					unknownUnit = true
+				}
+			}
+
+			if unknownUnit != test.wantUnknownUnit {
+				t.Errorf("unknown unit diagnostic = %t, want %t", unknownUnit, test.wantUnknownUnit)
+			}
+		})
+	}
+}